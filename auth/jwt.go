@@ -0,0 +1,236 @@
+// Package auth provides a bearer token middleware verifying JSON Web Tokens.
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// es256SignatureLength is the length of the r || s signature of ES256.
+const es256SignatureLength = 64
+
+var (
+	errMissingToken      = errors.New("missing bearer token")
+	errMalformedToken    = errors.New("malformed token")
+	errUnsupportedAlg    = errors.New("unsupported signing algorithm")
+	errInvalidSignature  = errors.New("invalid token signature")
+	errExpiredToken      = errors.New("token has expired")
+	errMissingExpiry     = errors.New("token has no expiry")
+	errTokenNotValidYet  = errors.New("token is not valid yet")
+	errInvalidIssuer     = errors.New("invalid token issuer")
+	errInvalidAudience   = errors.New("invalid token audience")
+	errInsufficientScope = errors.New("token does not grant access")
+)
+
+// Claims are the claims of a verified token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	// Raw holds every claim of the token, including the private ones.
+	Raw map[string]interface{}
+}
+
+// header is the JOSE header of a token.
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// registeredClaims are the registered claim names of RFC 7519.
+type registeredClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  audience    `json:"aud"`
+	ExpiresAt numericDate `json:"exp"`
+	NotBefore numericDate `json:"nbf"`
+	IssuedAt  numericDate `json:"iat"`
+}
+
+// audience is the aud claim, which is either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// numericDate is a JSON number of seconds since the epoch.
+type numericDate struct {
+	time.Time
+}
+
+func (nd *numericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	whole, fraction := math.Modf(seconds)
+	nd.Time = time.Unix(int64(whole), int64(fraction*1e9))
+	return nil
+}
+
+// Verifier verifies tokens against a KeySet and the expected claims.
+type Verifier struct {
+	cfg Config
+}
+
+// NewVerifier creates a new Verifier.
+func NewVerifier(cfg Config) *Verifier {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Verifier{cfg: cfg}
+}
+
+// Verify checks the token signature and its exp, nbf, iss and aud claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	var head header
+	if err := decodeJSONSegment(parts[0], &head); err != nil {
+		return nil, err
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedToken, err)
+	}
+	if err := v.verifySignature(head, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var registered registeredClaims
+	if err := decodeJSONSegment(parts[1], &registered); err != nil {
+		return nil, err
+	}
+	claims := &Claims{
+		Subject:   registered.Subject,
+		Issuer:    registered.Issuer,
+		Audience:  registered.Audience,
+		ExpiresAt: registered.ExpiresAt.Time,
+		NotBefore: registered.NotBefore.Time,
+		IssuedAt:  registered.IssuedAt.Time,
+	}
+	if err := decodeJSONSegment(parts[1], &claims.Raw); err != nil {
+		return nil, err
+	}
+
+	return claims, v.validateClaims(claims)
+}
+
+// verifySignature checks the signature against the candidate keys for the header.
+func (v *Verifier) verifySignature(head header, signingInput, signature []byte) error {
+	switch head.Algorithm {
+	case AlgHS256, AlgRS256, AlgES256:
+	default:
+		return fmt.Errorf("%w: %q", errUnsupportedAlg, head.Algorithm)
+	}
+	if v.cfg.Keys == nil {
+		return errUnknownKey
+	}
+
+	keys, err := v.cfg.Keys.lookup(head.KeyID, head.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(signingInput)
+	for _, key := range keys {
+		if verifyDigest(key, signingInput, digest[:], signature) {
+			return nil
+		}
+	}
+	return errInvalidSignature
+}
+
+// verifyDigest returns true if the signature was made by the key.
+func verifyDigest(key Key, signingInput, digest, signature []byte) bool {
+	switch publicKey := key.key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, publicKey)
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) != es256SignatureLength {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:es256SignatureLength/2])
+		s := new(big.Int).SetBytes(signature[es256SignatureLength/2:])
+		return ecdsa.Verify(publicKey, digest, r, s)
+	default:
+		return false
+	}
+}
+
+// validateClaims checks the time, issuer and audience claims.
+func (v *Verifier) validateClaims(claims *Claims) error {
+	now := v.cfg.Now()
+	if v.cfg.RequireExpiry && claims.ExpiresAt.IsZero() {
+		return errMissingExpiry
+	}
+	if !claims.ExpiresAt.IsZero() && now.After(claims.ExpiresAt.Add(v.cfg.ClockSkew)) {
+		return errExpiredToken
+	}
+	if !claims.NotBefore.IsZero() && now.Add(v.cfg.ClockSkew).Before(claims.NotBefore) {
+		return errTokenNotValidYet
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return errInvalidIssuer
+	}
+	if v.cfg.Audience != "" && !containsString(claims.Audience, v.cfg.Audience) {
+		return errInvalidAudience
+	}
+	return nil
+}
+
+// decodeJSONSegment decodes a base64url JSON segment of the token.
+func decodeJSONSegment(segment string, v interface{}) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedToken, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", errMalformedToken, err)
+	}
+	return nil
+}
+
+// containsString returns true if the value is in the slice.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testKeysOnce sync.Once
+	testRSAKey   *rsa.PrivateKey
+	testECKey    *ecdsa.PrivateKey
+)
+
+// testKeys returns the private keys tokens are signed with in the tests.
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		var err error
+		if testRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+		if testECKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			panic(err)
+		}
+	})
+	return testRSAKey, testECKey
+}
+
+// encodeSegment encodes the value as a base64url JSON segment.
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signToken creates a token for the claims, signed with the algorithm and key:
+// a secret for HS256, or a RSA or ECDSA private key.
+func signToken(t *testing.T, alg, keyID string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header{Algorithm: alg, KeyID: keyID}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, es256SignatureLength)
+		r.FillBytes(signature[:es256SignatureLength/2])
+		s.FillBytes(signature[es256SignatureLength/2:])
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifySignature(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	keys := NewKeySet(
+		HMACKey("hmac", secret),
+		RSAKey("rsa", &rsaKey.PublicKey),
+		ECDSAKey("ec", &ecKey.PublicKey),
+	)
+	claims := map[string]interface{}{"sub": "user-1"}
+	valid := signToken(t, AlgHS256, "hmac", secret, claims)
+	es256 := signToken(t, AlgES256, "ec", ecKey, claims)
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"HS256", valid, nil},
+		{"RS256", signToken(t, AlgRS256, "rsa", rsaKey, claims), nil},
+		{"ES256", es256, nil},
+		{"without key ID", signToken(t, AlgRS256, "", rsaKey, claims), nil},
+		{"wrong secret", signToken(t, AlgHS256, "hmac", []byte("another secret"), claims), errInvalidSignature},
+		{"wrong RSA key", signToken(t, AlgRS256, "rsa", otherRSAKey, claims), errInvalidSignature},
+		{"key of another algorithm", signToken(t, AlgES256, "rsa", ecKey, claims), errUnknownKey},
+		{"unknown key ID", signToken(t, AlgHS256, "other", secret, claims), errUnknownKey},
+		{"unsigned", signToken(t, "none", "", nil, claims), errUnsupportedAlg},
+		{"unsupported algorithm", signToken(t, "HS512", "hmac", secret, claims), errUnsupportedAlg},
+		{"tampered claims", strings.Replace(valid, strings.Split(valid, ".")[1], encodeSegment(t, map[string]interface{}{"sub": "admin"}), 1), errInvalidSignature},
+		{"truncated ES256 signature", es256[:len(es256)-4], errInvalidSignature},
+		{"two segments", "a.b", errMalformedToken},
+		{"invalid base64", "!.b.c", errMalformedToken},
+		{"invalid header", base64.RawURLEncoding.EncodeToString([]byte("{")) + ".b.c", errMalformedToken},
+	}
+	verifier := NewVerifier(Config{Keys: keys})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify: %v, want %v", err, tt.err)
+			}
+			if err == nil && claims.Subject != "user-1" {
+				t.Errorf("subject = %q", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("secret")
+	at := func(offset time.Duration) int64 {
+		return now.Add(offset).Unix()
+	}
+	base := Config{
+		Keys:      NewKeySet(HMACKey("", secret)),
+		Issuer:    "https://issuer.example.com",
+		Audience:  "orders-api",
+		ClockSkew: 30 * time.Second,
+		Now:       func() time.Time { return now },
+	}
+	requireExpiry := base
+	requireExpiry.RequireExpiry = true
+
+	tests := []struct {
+		name   string
+		cfg    Config
+		claims map[string]interface{}
+		err    error
+	}{
+		{"valid", base, map[string]interface{}{"exp": at(time.Minute), "nbf": at(-time.Minute)}, nil},
+		{"expired", base, map[string]interface{}{"exp": at(-31 * time.Second)}, errExpiredToken},
+		{"expired within the skew", base, map[string]interface{}{"exp": at(-29 * time.Second)}, nil},
+		{"not valid yet", base, map[string]interface{}{"nbf": at(31 * time.Second)}, errTokenNotValidYet},
+		{"not valid yet within the skew", base, map[string]interface{}{"nbf": at(29 * time.Second)}, nil},
+		{"without expiry", base, map[string]interface{}{}, nil},
+		{"expiry required", requireExpiry, map[string]interface{}{}, errMissingExpiry},
+		{"expiry required and set", requireExpiry, map[string]interface{}{"exp": at(time.Minute)}, nil},
+		{"wrong issuer", base, map[string]interface{}{"iss": "https://other.example.com"}, errInvalidIssuer},
+		{"missing issuer", base, map[string]interface{}{"iss": nil}, errInvalidIssuer},
+		{"audience in list", base, map[string]interface{}{"aud": []string{"billing-api", "orders-api"}}, nil},
+		{"wrong audience", base, map[string]interface{}{"aud": "billing-api"}, errInvalidAudience},
+		{"wrong audience list", base, map[string]interface{}{"aud": []string{"billing-api"}}, errInvalidAudience},
+		{"invalid expiry", base, map[string]interface{}{"exp": "tomorrow"}, errMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{
+				"sub":  "user-1",
+				"iss":  "https://issuer.example.com",
+				"aud":  "orders-api",
+				"role": "admin",
+			}
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+			verified, err := NewVerifier(tt.cfg).Verify(signToken(t, AlgHS256, "", secret, claims))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify: %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if verified.Subject != "user-1" || verified.Raw["role"] != "admin" {
+				t.Errorf("unexpected claims %+v", verified)
+			}
+			if exp, ok := tt.claims["exp"].(int64); ok && !verified.ExpiresAt.Equal(time.Unix(exp, 0)) {
+				t.Errorf("ExpiresAt = %v, want %v", verified.ExpiresAt, time.Unix(exp, 0))
+			}
+		})
+	}
+}
+
+func TestCodeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{errMissingToken, "401"},
+		{errMalformedToken, "10009"},
+		{errUnsupportedAlg, "401"},
+		{errUnknownKey, "401"},
+		{errInvalidSignature, "401"},
+		{errExpiredToken, "10010"},
+		{errMissingExpiry, "401"},
+		{errTokenNotValidYet, "401"},
+		{errInvalidIssuer, "401"},
+		{errInvalidAudience, "401"},
+		{errInsufficientScope, "403"},
+	}
+	for _, tt := range tests {
+		if code := codeError(tt.err); string(code) != tt.code {
+			t.Errorf("codeError(%v) = %s, want %s", tt.err, code, tt.code)
+		}
+	}
+}
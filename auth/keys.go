@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Signing algorithms supported by the middleware.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+var (
+	errUnknownKey     = errors.New("no key matches the token")
+	errUnsupportedKey = errors.New("unsupported JWK")
+)
+
+// Key is a verification key for a signing algorithm.
+type Key struct {
+	ID        string
+	Algorithm string
+	key       interface{}
+}
+
+// HMACKey creates a HS256 key from a shared secret.
+func HMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Algorithm: AlgHS256, key: secret}
+}
+
+// RSAKey creates a RS256 key from a RSA public key.
+func RSAKey(id string, publicKey *rsa.PublicKey) Key {
+	return Key{ID: id, Algorithm: AlgRS256, key: publicKey}
+}
+
+// ECDSAKey creates a ES256 key from a P-256 public key.
+func ECDSAKey(id string, publicKey *ecdsa.PublicKey) Key {
+	return Key{ID: id, Algorithm: AlgES256, key: publicKey}
+}
+
+// KeySet holds the keys tokens are verified against.
+type KeySet struct {
+	keys []Key
+}
+
+// NewKeySet creates a KeySet from static keys.
+func NewKeySet(keys ...Key) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// lookup finds the key for the token header.
+// Without a key ID every key for the algorithm is a candidate.
+func (ks *KeySet) lookup(keyID, algorithm string) ([]Key, error) {
+	var candidates []Key
+	for _, key := range ks.keys {
+		if key.Algorithm != algorithm {
+			continue
+		}
+		if keyID != "" && key.ID != keyID {
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+	if len(candidates) == 0 {
+		return nil, errUnknownKey
+	}
+	return candidates, nil
+}
+
+// jwk is a JSON Web Key as defined in RFC 7517.
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	K         string `json:"k"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// LoadJWKS reads a KeySet from a local JWKS file.
+// Keys that are not meant for signatures are skipped. Each key type verifies
+// a single algorithm: HS256 for oct, RS256 for RSA and ES256 for EC keys, so
+// keys with another alg are rejected.
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keySet := &KeySet{}
+	for i, raw := range document.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.key()
+		if err != nil {
+			return nil, fmt.Errorf("%s: keys[%d] (%s): %w", path, i, raw.KeyID, err)
+		}
+		if raw.Algorithm != "" && raw.Algorithm != key.Algorithm {
+			return nil, fmt.Errorf("%s: keys[%d] (%s): %w: alg %q for key type %q",
+				path, i, raw.KeyID, errUnsupportedKey, raw.Algorithm, raw.KeyType)
+		}
+		keySet.keys = append(keySet.keys, key)
+	}
+	return keySet, nil
+}
+
+// key converts the JWK into a Key.
+func (k jwk) key() (Key, error) {
+	switch k.KeyType {
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil {
+			return Key{}, err
+		}
+		return HMACKey(k.KeyID, secret), nil
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return Key{}, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return Key{}, err
+		}
+		return RSAKey(k.KeyID, &rsa.PublicKey{N: n, E: int(e.Int64())}), nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return Key{}, fmt.Errorf("%w: curve %q", errUnsupportedKey, k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return Key{}, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return Key{}, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !publicKey.Curve.IsOnCurve(x, y) {
+			return Key{}, fmt.Errorf("%w: point is not on the curve", errUnsupportedKey)
+		}
+		return ECDSAKey(k.KeyID, publicKey), nil
+	default:
+		return Key{}, fmt.Errorf("%w: key type %q", errUnsupportedKey, k.KeyType)
+	}
+}
+
+// decodeSegment decodes a base64url value without padding.
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// decodeBigInt decodes a base64url big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
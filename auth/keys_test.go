@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeJWKS writes a JWKS file with the keys.
+func writeJWKS(t *testing.T, keys ...map[string]string) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	octJWK := map[string]string{"kty": "oct", "kid": "hmac", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString(secret)}
+	rsaJWK := map[string]string{
+		"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+		"n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E))),
+	}
+	ecJWK := map[string]string{
+		"kty": "EC", "kid": "ec", "crv": "P-256",
+		"x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y),
+	}
+	encryption := map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "alg": "RSA-OAEP"}
+
+	keys, err := LoadJWKS(writeJWKS(t, octJWK, rsaJWK, ecJWK, encryption))
+	if err != nil {
+		t.Fatalf("LoadJWKS: %v", err)
+	}
+	if len(keys.keys) != 3 {
+		t.Fatalf("loaded %d keys, want 3 without the encryption key", len(keys.keys))
+	}
+	verifier := NewVerifier(Config{Keys: keys})
+	claims := map[string]interface{}{"sub": "user-1"}
+	for _, token := range []string{
+		signToken(t, AlgHS256, "hmac", secret, claims),
+		signToken(t, AlgRS256, "rsa", rsaKey, claims),
+		signToken(t, AlgES256, "ec", ecKey, claims),
+	} {
+		if _, err := verifier.Verify(token); err != nil {
+			t.Errorf("Verify: %v", err)
+		}
+	}
+}
+
+func TestLoadJWKSRejectsInvalidKeys(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	rsaJWK := func(alg string) map[string]string {
+		return map[string]string{
+			"kty": "RSA", "kid": "rsa", "alg": alg,
+			"n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E))),
+		}
+	}
+	tests := []struct {
+		name    string
+		key     map[string]string
+		message string
+	}{
+		{"RSA key for HS256", rsaJWK("HS256"), `alg "HS256" for key type "RSA"`},
+		{"unsupported algorithm", rsaJWK("RS512"), `alg "RS512" for key type "RSA"`},
+		{"oct key for RS256", map[string]string{"kty": "oct", "alg": "RS256", "k": "c2VjcmV0"}, `alg "RS256" for key type "oct"`},
+		{"EC key for ES384", map[string]string{
+			"kty": "EC", "crv": "P-256", "alg": "ES384",
+			"x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y),
+		}, `alg "ES384" for key type "EC"`},
+		{"unsupported curve", map[string]string{"kty": "EC", "crv": "P-384", "x": "AA", "y": "AA"}, `curve "P-384"`},
+		{"point not on the curve", map[string]string{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}, "not on the curve"},
+		{"unsupported key type", map[string]string{"kty": "OKP"}, `key type "OKP"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadJWKS(writeJWKS(t, tt.key))
+			if !errors.Is(err, errUnsupportedKey) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("LoadJWKS: %v, want an unsupported key error with %q", err, tt.message)
+			}
+		})
+	}
+}
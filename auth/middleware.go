@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger"
+	tlog "github.com/jimxshaw/tracerlogger/tracer/log"
+
+	"go.uber.org/zap"
+)
+
+// Config configures the token verification.
+type Config struct {
+	// Keys are the keys tokens are verified against.
+	Keys *KeySet
+	// Issuer is the expected iss claim. It is not checked when empty.
+	Issuer string
+	// Audience must be one of the aud claim values. It is not checked when empty.
+	Audience string
+	// ClockSkew is the leeway allowed when checking exp and nbf.
+	ClockSkew time.Duration
+	// RequireExpiry rejects tokens without an exp claim.
+	RequireExpiry bool
+	// Authorize optionally decides if verified claims grant access to the request.
+	// The context of the request already holds the claims, see ClaimsFromCtx.
+	Authorize func(r *http.Request, claims *Claims) bool
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// claimsCtxKey is the context key for the verified claims.
+type claimsCtxKey struct{}
+
+// ClaimsFromCtx returns the claims stored in the context by the middleware.
+func ClaimsFromCtx(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey{}).(*Claims)
+	return claims, ok
+}
+
+// InjectInCtx injects the claims in the context.
+// The subject is added to every log line written with the trace-aware logger.
+func InjectInCtx(ctx context.Context, claims *Claims) context.Context {
+	ctx = context.WithValue(ctx, claimsCtxKey{}, claims)
+	return tlog.WithFields(ctx, zap.String("subject", claims.Subject))
+}
+
+// Middleware verifies the bearer token of every request.
+// Requests without a valid token are answered with the matching CodeError:
+// CodeRequestTokenMalformed, CodeExpiredRequestToken, CodeUnauthorized, or
+// CodeForbidden when Authorize rejects the claims.
+func Middleware(cfg Config) func(next http.Handler) http.Handler {
+	verifier := NewVerifier(cfg)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respond(w, r, errMissingToken)
+				return
+			}
+
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", "invalid_token"))
+				respond(w, r, err)
+				return
+			}
+
+			r = r.WithContext(InjectInCtx(r.Context(), claims))
+			if cfg.Authorize != nil && !cfg.Authorize(r, claims) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", "insufficient_scope"))
+				respond(w, r, errInsufficientScope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken extracts the token from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// respond sends the error response for a token error.
+func respond(w http.ResponseWriter, r *http.Request, err error) {
+	code := codeError(err)
+	code.RespondTo(w, r, code.Status(), err)
+}
+
+// codeError maps a token error to its CodeError.
+func codeError(err error) tracerlogger.CodeError {
+	switch {
+	case errors.Is(err, errMalformedToken):
+		return tracerlogger.CodeRequestTokenMalformed
+	case errors.Is(err, errExpiredToken):
+		return tracerlogger.CodeExpiredRequestToken
+	case errors.Is(err, errInsufficientScope):
+		return tracerlogger.CodeForbidden
+	default:
+		return tracerlogger.CodeUnauthorized
+	}
+}
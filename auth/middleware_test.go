@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("secret")
+	sign := func(claims map[string]interface{}) string {
+		return "Bearer " + signToken(t, AlgHS256, "", secret, claims)
+	}
+	valid := sign(map[string]interface{}{"sub": "user-1", "exp": now.Add(time.Minute).Unix(), "role": "admin"})
+
+	var authorized *Claims
+	handler := Middleware(Config{
+		Keys: NewKeySet(HMACKey("", secret)),
+		Now:  func() time.Time { return now },
+		Authorize: func(r *http.Request, claims *Claims) bool {
+			authorized, _ = ClaimsFromCtx(r.Context())
+			return claims.Raw["role"] == "admin"
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromCtx(r.Context())
+		if !ok || claims.Subject != "user-1" {
+			t.Errorf("handler got the claims %+v", claims)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		code          string
+		authenticate  string
+	}{
+		{"valid", valid, http.StatusNoContent, "", ""},
+		{"missing token", "", http.StatusUnauthorized, "401", "Bearer"},
+		{"other scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "401", "Bearer"},
+		{"malformed", "Bearer abc", http.StatusUnauthorized, "10009", `Bearer error="invalid_token"`},
+		{"expired", sign(map[string]interface{}{"sub": "user-1", "exp": now.Add(-time.Minute).Unix()}), http.StatusUnauthorized, "10010", `Bearer error="invalid_token"`},
+		{"invalid signature", "Bearer " + signToken(t, AlgHS256, "", []byte("other"), map[string]interface{}{"sub": "user-1"}), http.StatusUnauthorized, "401", `Bearer error="invalid_token"`},
+		{"forbidden", sign(map[string]interface{}{"sub": "user-1", "role": "viewer"}), http.StatusForbidden, "403", `Bearer error="insufficient_scope"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorized = nil
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if authenticate := w.Header().Get("WWW-Authenticate"); authenticate != tt.authenticate {
+				t.Errorf("WWW-Authenticate = %q, want %q", authenticate, tt.authenticate)
+			}
+			if tt.code != "" {
+				var response struct {
+					Code string `json:"code"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Code != tt.code {
+					t.Errorf("response %s, want the code %s", w.Body, tt.code)
+				}
+			}
+			if tt.status == http.StatusNoContent || tt.status == http.StatusForbidden {
+				if authorized == nil || authorized.Subject != "user-1" {
+					t.Errorf("Authorize got the context claims %+v", authorized)
+				}
+			}
+		})
+	}
+}
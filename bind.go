@@ -0,0 +1,305 @@
+package tracerlogger
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locations of the values filled by a Binder.
+const (
+	LocationPath   = "path"
+	LocationQuery  = "query"
+	LocationHeader = "header"
+)
+
+var (
+	errBindTarget      = errors.New("bind target must be a non-nil pointer to a struct")
+	errUnsupportedKind = errors.New("unsupported field type")
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// PathParams returns the value of a route variable of the request,
+// or an empty string if the route has no such variable.
+type PathParams func(r *http.Request, name string) string
+
+// StdlibPathParams reads route variables from the patterns of http.ServeMux.
+func StdlibPathParams(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+
+// ChiPathParams adapts chi's URLParam, e.g. ChiPathParams(chi.URLParam).
+func ChiPathParams(urlParam func(r *http.Request, key string) string) PathParams {
+	return PathParams(urlParam)
+}
+
+// GorillaPathParams adapts gorilla/mux's Vars, e.g. GorillaPathParams(mux.Vars).
+func GorillaPathParams(vars func(r *http.Request) map[string]string) PathParams {
+	return func(r *http.Request, name string) string {
+		return vars(r)[name]
+	}
+}
+
+// Binder fills structs from the route variables, query string and headers of a request.
+//
+// Fields are selected with the path, query and header struct tags. Route
+// variables are always required, query parameters and headers only with the
+// required option:
+//
+//	type params struct {
+//		ID      int       `path:"id"`
+//		Page    int       `query:"page"`
+//		Tags    []string  `query:"tag"`
+//		Since   time.Time `query:"since,required"`
+//		Request string    `header:"X-Request-ID"`
+//	}
+//
+// Supported field types are strings, booleans, numbers, time.Duration,
+// time.Time (RFC 3339), encoding.TextUnmarshaler, and pointers and slices of those.
+// Fields of embedded structs are filled too. Nil embedded pointers are
+// allocated when one of their fields has a value, except unexported ones,
+// whose fields are then left unset.
+type Binder struct {
+	pathParams PathParams
+	fields     sync.Map
+}
+
+// DefaultBinder is the Binder used by Bind, reading route variables from http.ServeMux.
+var DefaultBinder = NewBinder(StdlibPathParams)
+
+// NewBinder creates a new Binder reading route variables with pathParams.
+func NewBinder(pathParams PathParams) *Binder {
+	return &Binder{pathParams: pathParams}
+}
+
+// Bind fills dst using DefaultBinder.
+func Bind(r *http.Request, dst interface{}) error {
+	return DefaultBinder.Bind(r, dst)
+}
+
+// Bind fills the struct dst points to from the request.
+// Missing or unparseable values are reported together as a ResponseError
+// with a FieldError per value, so the result can be sent with Respond.
+func (b *Binder) Bind(r *http.Request, dst interface{}) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return errBindTarget
+	}
+	target = target.Elem()
+
+	fields, err := b.structFields(target.Type())
+	if err != nil {
+		return err
+	}
+
+	response := ResponseError{}
+	query := r.URL.Query()
+	for _, field := range fields {
+		var values []string
+		switch field.location {
+		case LocationPath:
+			if value := b.pathParams(r, field.name); value != "" {
+				values = []string{value}
+			}
+		case LocationQuery:
+			values = query[field.name]
+		case LocationHeader:
+			values = r.Header.Values(field.name)
+		}
+
+		if len(values) == 0 {
+			switch {
+			case field.location == LocationPath:
+				response.AddLocatedValidationError(CodeRouteVariableRequired, field.location, field.name, "")
+			case field.required:
+				response.AddLocatedValidationError(CodeFieldRequired, field.location, field.name, "")
+			}
+			continue
+		}
+
+		value, ok := fieldByIndex(target, field.index)
+		if !ok {
+			continue
+		}
+		if err := setValue(value, values); err != nil {
+			var numErr *strconv.NumError
+			if errors.As(err, &numErr) {
+				err = numErr.Err
+			}
+			response.AddLocatedValidationError(
+				CodeFieldInvalidValue,
+				field.location,
+				field.name,
+				fmt.Sprintf("The field in the request has an invalid value: %v", err),
+			)
+		}
+	}
+
+	if len(response.Errors) > 0 {
+		return response
+	}
+	return nil
+}
+
+// bindField describes a struct field filled by a Binder.
+type bindField struct {
+	index    []int
+	name     string
+	location string
+	required bool
+}
+
+// structFields returns the fields of the struct type filled by the Binder.
+// The result is cached per type.
+func (b *Binder) structFields(t reflect.Type) ([]bindField, error) {
+	if cached, ok := b.fields.Load(t); ok {
+		return cached.([]bindField), nil
+	}
+
+	var fields []bindField
+	for _, structField := range reflect.VisibleFields(t) {
+		if !structField.IsExported() {
+			continue
+		}
+		for _, location := range []string{LocationPath, LocationQuery, LocationHeader} {
+			tag, ok := structField.Tag.Lookup(location)
+			if !ok || tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = structField.Name
+			}
+			if !isBindable(structField.Type) {
+				return nil, fmt.Errorf("%w: %s %s", errUnsupportedKind, structField.Name, structField.Type)
+			}
+			fields = append(fields, bindField{
+				index:    structField.Index,
+				name:     name,
+				location: location,
+				required: options == "required",
+			})
+		}
+	}
+
+	b.fields.Store(t, fields)
+	return fields, nil
+}
+
+// fieldByIndex returns the nested field of the index, allocating the nil
+// embedded struct pointers on the way. It returns false if one cannot be
+// allocated because it is unexported.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isBindable returns true if setValue can fill values of the type.
+func isBindable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return isBindable(t.Elem())
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && isBindable(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setValue parses the values into the field.
+// Slices get every value, other types only the first.
+func setValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !field.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	return parseValue(field, values[0])
+}
+
+// parseValue parses a single value into the field.
+func parseValue(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch field.Interface().(type) {
+	case time.Duration:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedKind, field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package tracerlogger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type Page struct {
+	Number int `query:"page"`
+	Size   int `query:"size"`
+}
+
+type cursor struct {
+	After string `query:"after"`
+}
+
+func TestBind(t *testing.T) {
+	var params struct {
+		ID      int           `path:"id"`
+		Tags    []string      `query:"tag"`
+		Since   *time.Time    `query:"since"`
+		Timeout time.Duration `query:"timeout"`
+		Request string        `header:"X-Request-ID"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/orders/42?tag=a&tag=b&since=2024-01-01T00:00:00Z&timeout=2s", nil)
+	r.SetPathValue("id", "42")
+	r.Header.Set("X-Request-ID", "r-1")
+
+	if err := Bind(r, &params); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if params.ID != 42 || len(params.Tags) != 2 || params.Tags[1] != "b" || params.Timeout != 2*time.Second ||
+		params.Since == nil || params.Since.Year() != 2024 || params.Request != "r-1" {
+		t.Errorf("unexpected params %+v", params)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	var params struct {
+		ID    int `path:"id"`
+		Limit int `query:"limit,required"`
+		Page  int `query:"page"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/orders?page=first", nil)
+
+	var response ResponseError
+	if err := Bind(r, &params); !errors.As(err, &response) {
+		t.Fatalf("Bind: %v, want a ResponseError", err)
+	}
+	want := []FieldError{
+		{Code: string(CodeRouteVariableRequired), Location: LocationPath, Field: "id"},
+		{Code: string(CodeFieldRequired), Location: LocationQuery, Field: "limit"},
+		{Code: string(CodeFieldInvalidValue), Location: LocationQuery, Field: "page"},
+	}
+	if len(response.Errors) != len(want) {
+		t.Fatalf("got the errors %+v", response.Errors)
+	}
+	for i, fe := range response.Errors {
+		if fe.Code != want[i].Code || fe.Location != want[i].Location || fe.Field != want[i].Field {
+			t.Errorf("error %d = %+v, want %+v", i, fe, want[i])
+		}
+	}
+}
+
+func TestBindEmbeddedPointers(t *testing.T) {
+	type params struct {
+		*Page
+		*cursor
+		Query string `query:"q"`
+	}
+
+	var empty params
+	if err := Bind(httptest.NewRequest(http.MethodGet, "/orders?q=x", nil), &empty); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if empty.Page != nil {
+		t.Errorf("allocated the page without values: %+v", empty.Page)
+	}
+
+	var filled params
+	if err := Bind(httptest.NewRequest(http.MethodGet, "/orders?page=2&after=c1", nil), &filled); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if filled.Page == nil || filled.Number != 2 || filled.Size != 0 {
+		t.Errorf("unexpected page %+v", filled.Page)
+	}
+	if filled.cursor != nil {
+		t.Errorf("allocated the unexported cursor: %+v", filled.cursor)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+// funcs are the helpers available to the templates.
+var funcs = template.FuncMap{
+	"sentence": sentence,
+	"quote":    func(s string) string { return fmt.Sprintf("%q", s) },
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(funcs).Parse(`// Code generated by errgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+const (
+{{- range .Groups}}
+	// {{.Description}}
+{{range .Errors}}
+	// {{.ConstName}} is the {{quote .Title}} error sent with HTTP status {{.Status}}.
+	// {{sentence .Message}}
+{{- if .Deprecated}}
+	//
+	// Deprecated: {{.Deprecated}}
+{{- end}}
+	{{.ConstName}} CodeError = {{quote .Code}}
+{{- end}}
+{{end -}}
+)
+
+var codeErrors map[CodeError]ResponseError = map[CodeError]ResponseError{
+{{- range .Groups}}
+	// {{.Description}}
+{{- range .Errors}}
+	{{.ConstName}}: {
+		Code:    string({{.ConstName}}),
+		Title:   {{quote .Title}},
+		Message: {{quote .Message}},
+	},
+{{- end}}
+{{- end}}
+}
+
+var codeStatuses map[CodeError]int = map[CodeError]int{
+{{- range .Groups}}
+{{- range .Errors}}
+	{{.ConstName}}: {{.Status}},
+{{- end}}
+{{- end}}
+}
+`))
+
+var docsTemplate = template.Must(template.New("docs").Funcs(funcs).Parse(`<!-- Code generated by errgen from {{.Source}}. DO NOT EDIT. -->
+
+# Error codes
+{{range .Groups}}
+## {{.Description}}
+
+| Code | Constant | HTTP status | Title | Message |
+| ---- | -------- | ----------- | ----- | ------- |
+{{range .Errors}}| {{.Code}} | ` + "`{{.ConstName}}`" + `{{if .Deprecated}} (deprecated){{end}} | {{.Status}} | {{.Title}} | {{.Message}} |
+{{end}}{{end}}`))
+
+// templateData is passed to the templates.
+type templateData struct {
+	Source  string
+	Package string
+	Groups  []categoryErrors
+}
+
+// generateGo renders the constants and registry entries as formatted Go source.
+func generateGo(data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// generateDocs renders the markdown documentation of the error codes.
+func generateDocs(data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	err := docsTemplate.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+// sentence makes sure the message ends with punctuation for use in comments.
+func sentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s[len(s)-1:], ".!?") {
+		return s
+	}
+	return s + "."
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCommittedFiles checks that the committed files match errors.yaml.
+func TestCommittedFiles(t *testing.T) {
+	root := filepath.Join("..", "..")
+	dir := t.TempDir()
+	out, docs := filepath.Join(dir, "const.go"), filepath.Join(dir, "ERRORS.md")
+	if err := run(filepath.Join(root, "errors.yaml"), out, docs, "tracerlogger"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	for committed, generated := range map[string]string{"const.go": out, "ERRORS.md": docs} {
+		want, err := os.ReadFile(filepath.Join(root, committed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(generated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date with errors.yaml: run go generate in the repository root", committed)
+		}
+	}
+}
+
+func TestRunRequiresPackage(t *testing.T) {
+	t.Setenv("GOPACKAGE", "")
+	dir := t.TempDir()
+	if err := run(filepath.Join("..", "..", "errors.yaml"), filepath.Join(dir, "const.go"), "", ""); err == nil {
+		t.Error("run generated a file without package name")
+	}
+}
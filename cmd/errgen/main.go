@@ -0,0 +1,68 @@
+// Command errgen generates the CodeError constants, their registry entries and
+// their documentation from an error specification file.
+//
+// Usage:
+//
+//	//go:generate go run ./cmd/errgen -spec errors.yaml -out const.go -docs ERRORS.md
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "errors.yaml", "YAML or JSON error specification")
+	outPath := flag.String("out", "const.go", "generated Go file")
+	docsPath := flag.String("docs", "", "generated markdown documentation, skipped when empty")
+	pkg := flag.String("package", "", "package name of the generated file, defaults to $GOPACKAGE")
+	flag.Parse()
+
+	if err := run(*specPath, *outPath, *docsPath, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "errgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run loads and validates the specification, then writes the generated files.
+func run(specPath, outPath, docsPath, pkg string) error {
+	if pkg == "" {
+		pkg = os.Getenv("GOPACKAGE")
+	}
+	if pkg == "" {
+		return fmt.Errorf("package name is required outside of go generate")
+	}
+
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	data := templateData{
+		Source:  filepath.Base(specPath),
+		Package: pkg,
+		Groups:  spec.grouped(),
+	}
+
+	source, err := generateGo(data)
+	if err != nil {
+		return fmt.Errorf("generating %s: %w", outPath, err)
+	}
+	if err := os.WriteFile(outPath, source, 0o644); err != nil {
+		return err
+	}
+
+	if docsPath == "" {
+		return nil
+	}
+	docs, err := generateDocs(data)
+	if err != nil {
+		return fmt.Errorf("generating %s: %w", docsPath, err)
+	}
+	return os.WriteFile(docsPath, docs, 0o644)
+}
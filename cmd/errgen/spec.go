@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// codeRegex matches the numeric error codes.
+var codeRegex = regexp.MustCompile(`^[0-9]{1,5}$`)
+
+// Category groups error codes within a numeric range.
+type Category struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Min         int    `json:"min" yaml:"min"`
+	Max         int    `json:"max" yaml:"max"`
+}
+
+// ErrorSpec describes a single error code.
+type ErrorSpec struct {
+	Code       string `json:"code" yaml:"code"`
+	Name       string `json:"name" yaml:"name"`
+	Title      string `json:"title" yaml:"title"`
+	Message    string `json:"message" yaml:"message"`
+	Status     int    `json:"status" yaml:"status"`
+	Category   string `json:"category" yaml:"category"`
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// ConstName returns the name of the Go constant for the error.
+func (es ErrorSpec) ConstName() string {
+	return "Code" + es.Name
+}
+
+// Spec is the error specification file.
+type Spec struct {
+	Categories []Category  `json:"categories" yaml:"categories"`
+	Errors     []ErrorSpec `json:"errors" yaml:"errors"`
+}
+
+// loadSpec reads a YAML or JSON specification, chosen by the file extension.
+func loadSpec(path string) (Spec, error) {
+	var spec Spec
+	file, err := os.Open(path)
+	if err != nil {
+		return spec, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&spec)
+	} else {
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&spec)
+	}
+	if err != nil {
+		return spec, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// validate checks the specification for duplicates and malformed entries.
+// All problems are reported together.
+func (s Spec) validate() error {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	categories := map[string]Category{}
+	for _, category := range s.Categories {
+		if category.Name == "" {
+			report("category without name")
+			continue
+		}
+		if _, exists := categories[category.Name]; exists {
+			report("duplicate category %q", category.Name)
+		}
+		if category.Min > category.Max {
+			report("category %q: min %d is greater than max %d", category.Name, category.Min, category.Max)
+		}
+		categories[category.Name] = category
+	}
+
+	codes := map[string]string{}
+	names := map[string]string{}
+	for i, es := range s.Errors {
+		entry := fmt.Sprintf("errors[%d] (%s)", i, es.Code)
+		wellFormed := codeRegex.MatchString(es.Code)
+		if !wellFormed {
+			report("%s: malformed code %q, must be 1 to 5 digits", entry, es.Code)
+		} else if other, exists := codes[es.Code]; exists {
+			report("%s: duplicate code, already used by %s", entry, other)
+		} else {
+			codes[es.Code] = es.Name
+		}
+
+		if !token.IsIdentifier(es.Name) || !token.IsExported(es.Name) {
+			report("%s: name %q must be an exported Go identifier", entry, es.Name)
+		} else if other, exists := names[es.Name]; exists {
+			report("%s: duplicate name %q, already used by code %s", entry, es.Name, other)
+		} else {
+			names[es.Name] = es.Code
+		}
+
+		if es.Title == "" {
+			report("%s: title is required", entry)
+		}
+		if es.Status == 0 {
+			report("%s: status is required", entry)
+		} else if es.Status < 100 || es.Status > 599 {
+			report("%s: status %d is not a valid HTTP status", entry, es.Status)
+		}
+
+		category, exists := categories[es.Category]
+		if !exists {
+			report("%s: unknown category %q", entry, es.Category)
+			continue
+		}
+		if code, err := strconv.Atoi(es.Code); wellFormed && err == nil && (code < category.Min || code > category.Max) {
+			report("%s: code is outside the %q range %d - %d", entry, category.Name, category.Min, category.Max)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid error specification:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// grouped returns the errors of each category in specification order.
+func (s Spec) grouped() []categoryErrors {
+	groups := make([]categoryErrors, 0, len(s.Categories))
+	for _, category := range s.Categories {
+		group := categoryErrors{Category: category}
+		for _, es := range s.Errors {
+			if es.Category == category.Name {
+				group.Errors = append(group.Errors, es)
+			}
+		}
+		if len(group.Errors) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// categoryErrors is a category with its errors.
+type categoryErrors struct {
+	Category
+	Errors []ErrorSpec
+}
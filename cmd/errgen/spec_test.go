@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSpec writes the specification to a file with the extension.
+func writeSpec(t *testing.T, ext, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "errors"+ext)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const specCategories = `categories:
+  - name: general
+    description: General errors
+    min: 0
+    max: 9999
+`
+
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		errors   string
+		problems []string
+	}{
+		{
+			name: "valid",
+			errors: `
+  - {code: "400", name: BadRequest, title: Bad Request, status: 400, category: general}
+  - {code: "404", name: NotFound, title: Not Found, status: 404, category: general}`,
+		},
+		{
+			name: "duplicate code",
+			errors: `
+  - {code: "400", name: BadRequest, title: Bad Request, status: 400, category: general}
+  - {code: "400", name: InvalidInput, title: Invalid Input, status: 400, category: general}`,
+			problems: []string{`errors[1] (400): duplicate code, already used by BadRequest`},
+		},
+		{
+			name: "duplicate name",
+			errors: `
+  - {code: "400", name: BadRequest, title: Bad Request, status: 400, category: general}
+  - {code: "401", name: BadRequest, title: Unauthorized, status: 401, category: general}`,
+			problems: []string{`errors[1] (401): duplicate name "BadRequest", already used by code 400`},
+		},
+		{
+			name: "malformed codes",
+			errors: `
+  - {code: "4x0", name: BadRequest, title: Bad Request, status: 400, category: general}
+  - {code: "123456", name: TooLong, title: Too Long, status: 400, category: general}
+  - {code: "", name: Empty, title: Empty, status: 400, category: general}`,
+			problems: []string{
+				`errors[0] (4x0): malformed code "4x0", must be 1 to 5 digits`,
+				`errors[1] (123456): malformed code "123456", must be 1 to 5 digits`,
+				`errors[2] (): malformed code "", must be 1 to 5 digits`,
+			},
+		},
+		{
+			name: "missing and invalid status",
+			errors: `
+  - {code: "400", name: BadRequest, title: Bad Request, category: general}
+  - {code: "401", name: Unauthorized, title: Unauthorized, status: 99, category: general}`,
+			problems: []string{
+				`errors[0] (400): status is required`,
+				`errors[1] (401): status 99 is not a valid HTTP status`,
+			},
+		},
+		{
+			name: "invalid entries",
+			errors: `
+  - {code: "10000", name: badRequest, status: 400, category: general}
+  - {code: "400", name: Unknown, title: Unknown, status: 400, category: other}`,
+			problems: []string{
+				`errors[0] (10000): name "badRequest" must be an exported Go identifier`,
+				`errors[0] (10000): title is required`,
+				`errors[0] (10000): code is outside the "general" range 0 - 9999`,
+				`errors[1] (400): unknown category "other"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := loadSpec(writeSpec(t, ".yaml", specCategories+"errors:"+tt.errors+"\n"))
+			if err != nil {
+				t.Fatalf("loadSpec: %v", err)
+			}
+			err = spec.validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validate accepted the specification")
+			}
+			problems := strings.Split(err.Error(), "\n\t")[1:]
+			if strings.Join(problems, "\n") != strings.Join(tt.problems, "\n") {
+				t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(tt.problems, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadSpecRejectsUnknownFields(t *testing.T) {
+	yamlSpec := specCategories + `errors:
+  - {code: "400", name: BadRequest, title: Bad Request, status: 400, category: general, http: 400}
+`
+	if _, err := loadSpec(writeSpec(t, ".yaml", yamlSpec)); err == nil {
+		t.Error("YAML specification with an unknown field loaded")
+	}
+	jsonSpec := `{"categories": [], "errors": [{"code": "400", "stauts": 400}]}`
+	if _, err := loadSpec(writeSpec(t, ".json", jsonSpec)); err == nil {
+		t.Error("JSON specification with an unknown field loaded")
+	}
+}
@@ -1,42 +1,60 @@
+// Code generated by errgen from errors.yaml. DO NOT EDIT.
+
 package tracerlogger
 
 const (
 	// General errors 0 - 9999
 
-	// CodeBadRequest - CodeError BadRequest
+	// CodeBadRequest is the "Bad Request" error sent with HTTP status 400.
+	// Failed to complete request due to a bad request.
 	CodeBadRequest CodeError = "400"
-	// CodeUnauthorized - CodeError Unauthorized
+	// CodeUnauthorized is the "Unauthorized" error sent with HTTP status 401.
+	// The user must be authenticated.
 	CodeUnauthorized CodeError = "401"
-	// CodeForbidden - CodeError Forbidden
+	// CodeForbidden is the "Forbidden" error sent with HTTP status 403.
+	// The user does not have sufficient permissions.
 	CodeForbidden CodeError = "403"
-	// CodeNotFound - CodeError NotFound
+	// CodeNotFound is the "Not Found" error sent with HTTP status 404.
+	// Failed to find a match for the request.
 	CodeNotFound CodeError = "404"
-	// CodeInternalServerError - CodeError InternalServerError
+	// CodeInternalServerError is the "Internal Server Error" error sent with HTTP status 500.
+	// Something went wrong. Please report the issue to Administrators.
 	CodeInternalServerError CodeError = "500"
 
 	// Hygiene and Validation errors 1XXXX
 
-	// CodeFieldsValidation - CodeError FieldsValidation
+	// CodeFieldsValidation is the "Fields Validation" error sent with HTTP status 400.
+	// Multiple fields errors.
 	CodeFieldsValidation CodeError = "10000"
-	// CodeUniqueFieldValidation - CodeError UniqueFieldValidation
+	// CodeUniqueFieldValidation is the "Unique Field Validation" error sent with HTTP status 409.
+	// Unique field resource already exists.
 	CodeUniqueFieldValidation CodeError = "10001"
-	// CodeFieldMaxLength - CodeError FieldMaxLength
+	// CodeFieldMaxLength is the "Field Max Length" error sent with HTTP status 400.
+	// The field length in the request is greater than maximum length.
 	CodeFieldMaxLength CodeError = "10002"
-	// CodeFieldRequired - CodeError FieldRequired
+	// CodeFieldRequired is the "Field Required" error sent with HTTP status 400.
+	// The field in the request is required.
 	CodeFieldRequired CodeError = "10003"
-	// CodeRouteVariableRequired - CodeError RouteVariableRequired
+	// CodeRouteVariableRequired is the "Route Variable Required" error sent with HTTP status 400.
+	// The route variable for the request is required.
 	CodeRouteVariableRequired CodeError = "10004"
-	// CodeFieldMinValue - CodeError FieldMinValue
+	// CodeFieldMinValue is the "Field Minimum Value" error sent with HTTP status 400.
+	// The field in the request is less than minimum value.
 	CodeFieldMinValue CodeError = "10005"
-	// CodeFieldInvalidValue - CodeError FieldInvalidValue
+	// CodeFieldInvalidValue is the "Field Invalid Value" error sent with HTTP status 400.
+	// The field in the request has an invalid value.
 	CodeFieldInvalidValue CodeError = "10006"
-	// CodeRequestPayloadMalformed - CodeError RequestPayloadMalformed
+	// CodeRequestPayloadMalformed is the "Payload Malformed" error sent with HTTP status 400.
+	// The payload for the request is malformed.
 	CodeRequestPayloadMalformed CodeError = "10007"
-	// CodeFieldNotMatchRegex - CodeError FieldNotMatchRegex
+	// CodeFieldNotMatchRegex is the "Field Not Match Regex" error sent with HTTP status 400.
+	// The field in the request does not match regular expression format.
 	CodeFieldNotMatchRegex CodeError = "10008"
-	// CodeRequestTokenMalformed - CodeError RequestTokenMalformed
+	// CodeRequestTokenMalformed is the "Token Malformed" error sent with HTTP status 401.
+	// The token for the request is malformed.
 	CodeRequestTokenMalformed CodeError = "10009"
-	// CodeExpiredRequestToken - CodeError ExpiredRequestToken
+	// CodeExpiredRequestToken is the "Expired Token" error sent with HTTP status 401.
+	// The request token has expired.
 	CodeExpiredRequestToken CodeError = "10010"
 )
 
@@ -124,3 +142,22 @@ var codeErrors map[CodeError]ResponseError = map[CodeError]ResponseError{
 		Message: "The request token has expired",
 	},
 }
+
+var codeStatuses map[CodeError]int = map[CodeError]int{
+	CodeBadRequest:              400,
+	CodeUnauthorized:            401,
+	CodeForbidden:               403,
+	CodeNotFound:                404,
+	CodeInternalServerError:     500,
+	CodeFieldsValidation:        400,
+	CodeUniqueFieldValidation:   409,
+	CodeFieldMaxLength:          400,
+	CodeFieldRequired:           400,
+	CodeRouteVariableRequired:   400,
+	CodeFieldMinValue:           400,
+	CodeFieldInvalidValue:       400,
+	CodeRequestPayloadMalformed: 400,
+	CodeFieldNotMatchRegex:      400,
+	CodeRequestTokenMalformed:   401,
+	CodeExpiredRequestToken:     401,
+}
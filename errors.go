@@ -6,10 +6,13 @@ import (
 	"strings"
 
 	log "github.com/jimxshaw/tracerlogger/logger"
+	tlog "github.com/jimxshaw/tracerlogger/tracer/log"
 
 	"go.uber.org/zap"
 )
 
+//go:generate go run ./cmd/errgen -spec errors.yaml -out const.go -docs ERRORS.md
+
 // Error interface for HTTP error responses.
 type Error interface {
 	CodeError() CodeError
@@ -30,6 +33,16 @@ func (ce CodeError) ResponseError() (ResponseError, bool) {
 	return value, true
 }
 
+// Status returns the HTTP status code the CodeError is sent with.
+// If the CodeError is not found, it defaults to http.StatusInternalServerError.
+func (ce CodeError) Status() int {
+	status, exists := codeStatuses[ce]
+	if !exists {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
 // String returns a formatted string representation of the CodeError.
 func (ce CodeError) String() string {
 	rr, exists := ce.ResponseError()
@@ -59,11 +72,20 @@ func (ce CodeError) Respond(w http.ResponseWriter, code int, err error) {
 	response.Respond(w, code, err)
 }
 
+// RespondTo sends an HTTP error response corresponding to the CodeError,
+// negotiating between HTML and JSON using the request's Accept header.
+func (ce CodeError) RespondTo(w http.ResponseWriter, r *http.Request, code int, err error) {
+	response, _ := ce.ResponseError()
+	response.RespondTo(w, r, code, err)
+}
+
 // FieldError represents an error associated with a specific field.
 type FieldError struct {
 	Code    string `json:"code"`
 	Field   string `json:"field"`
 	Message string `json:"message,omitempty"`
+	// Location is where the field was read from, e.g. path, query or header.
+	Location string `json:"location,omitempty"`
 }
 
 // String returns a formatted string representation of the FieldError.
@@ -138,10 +160,22 @@ func newGlobalErrorResponse(
 
 // AddValidationError appends a FieldError to ResponseError's Errors slice.
 func (re *ResponseError) AddValidationError(code CodeError, field, message string) {
+	re.addFieldError(code, field, "", message)
+}
+
+// AddLocatedValidationError appends a FieldError for a field read from location,
+// e.g. a route variable, query parameter or header, to ResponseError's Errors slice.
+func (re *ResponseError) AddLocatedValidationError(code CodeError, location, field, message string) {
+	re.addFieldError(code, field, location, message)
+}
+
+// addFieldError appends a FieldError to ResponseError's Errors slice.
+func (re *ResponseError) addFieldError(code CodeError, field, location, message string) {
 	validationErr := FieldError{
-		Code:    string(code),
-		Field:   field,
-		Message: message,
+		Code:     string(code),
+		Field:    field,
+		Message:  message,
+		Location: location,
 	}
 
 	responseError, exists := code.ResponseError()
@@ -161,10 +195,36 @@ func (re ResponseError) Respond(w http.ResponseWriter, code int, err error) {
 	if err == nil {
 		logErr = re
 	}
-	log.Error("request with error", zap.Error(logErr))
+	log.Error("request with error", log.ErrorField(logErr))
+
+	response := newGlobalErrorResponse(re, err)
+	RespondWithJSON(w, code, response)
+	fireHooks(newErrorEvent(w, nil, re.CodeError(), code, logErr))
+}
+
+// RespondTo sends an HTTP error response using the ResponseError structure.
+// Clients that prefer text/html get a page rendered by DefaultHTMLRenderer,
+// everyone else gets the same JSON response as Respond.
+func (re ResponseError) RespondTo(w http.ResponseWriter, r *http.Request, code int, err error) {
+	logErr := err
+	if err == nil {
+		logErr = re
+	}
+	tlog.Error(r.Context(), "request with error", log.ErrorField(logErr))
+
+	if prefersHTML(r) {
+		page := newHTMLPage(r, re, code)
+		renderErr := DefaultHTMLRenderer.Render(w, code, page)
+		if renderErr == nil {
+			fireHooks(newErrorEvent(w, r, re.CodeError(), code, logErr))
+			return
+		}
+		tlog.Error(r.Context(), "failed to render HTML error page", zap.Error(renderErr))
+	}
 
 	response := newGlobalErrorResponse(re, err)
 	RespondWithJSON(w, code, response)
+	fireHooks(newErrorEvent(w, r, re.CodeError(), code, logErr))
 }
 
 // updateIfValidationError sets the Code and Title of the ResponseError based on validation errors.
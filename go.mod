@@ -1,7 +1,10 @@
 module github.com/jimxshaw/tracerlogger
 
-go 1.20
+go 1.22
 
-require go.uber.org/zap v1.26.0
+require (
+	go.uber.org/zap v1.26.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,276 @@
+package tracerlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+	"github.com/jimxshaw/tracerlogger/tracer"
+
+	"go.uber.org/zap"
+)
+
+// ErrorEvent describes an error response that has been sent.
+type ErrorEvent struct {
+	Code   CodeError
+	Status int
+	Cause  error
+	// Request is nil when the response was sent through Respond.
+	Request *http.Request
+	// Trace is empty when the request was not handled by the trace middleware.
+	Trace tracer.TraceField
+	Time  time.Time
+}
+
+// Hook reacts to error responses.
+// Hooks run synchronously after the response is written, so slow work should
+// be bounded by a timeout or moved to a goroutine.
+type Hook interface {
+	Fire(event ErrorEvent)
+}
+
+// HookFunc is an adapter to allow the use of ordinary functions as hooks.
+type HookFunc func(event ErrorEvent)
+
+// Fire calls f(event).
+func (f HookFunc) Fire(event ErrorEvent) {
+	f(event)
+}
+
+// hookRegistry stores the hooks invoked on every error response.
+type hookRegistry struct {
+	mu     sync.RWMutex
+	nextID int
+	hooks  map[int]Hook
+}
+
+var hooks = &hookRegistry{hooks: map[int]Hook{}}
+
+// RegisterHook adds a hook invoked on every error response.
+// The returned function removes the hook again.
+func RegisterHook(hook Hook) (unregister func()) {
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	id := hooks.nextID
+	hooks.nextID++
+	hooks.hooks[id] = hook
+
+	return func() {
+		hooks.mu.Lock()
+		defer hooks.mu.Unlock()
+		delete(hooks.hooks, id)
+	}
+}
+
+// fireHooks invokes the registered hooks with the event.
+func fireHooks(event ErrorEvent) {
+	hooks.mu.RLock()
+	registered := make([]Hook, 0, len(hooks.hooks))
+	for _, hook := range hooks.hooks {
+		registered = append(registered, hook)
+	}
+	hooks.mu.RUnlock()
+
+	for _, hook := range registered {
+		hook.Fire(event)
+	}
+}
+
+// newErrorEvent creates the ErrorEvent for a response sent with the writer and, when known, the request.
+func newErrorEvent(w http.ResponseWriter, r *http.Request, ce CodeError, code int, err error) ErrorEvent {
+	event := ErrorEvent{
+		Code:    ce,
+		Status:  code,
+		Cause:   err,
+		Request: r,
+		Time:    time.Now(),
+	}
+	if r != nil {
+		if tc, ok := tracer.FromCtx(r.Context()); ok {
+			event.Trace = tc.Sanitize()
+		}
+	} else if tc, ok := w.(*tracer.TracerContext); ok {
+		event.Trace = tc.Sanitize()
+	}
+	return event
+}
+
+// codeOf returns the CodeError of the first error in the chain carrying one.
+// Other errors get the general CodeError of the status, e.g. CodeNotFound for
+// a 404, or none when there is no such code.
+func codeOf(err error, status int) CodeError {
+	var coded interface{ CodeError() CodeError }
+	if errors.As(err, &coded) {
+		// The StackErrors of WithStack have no code of their own.
+		if se, ok := coded.(*StackError); ok && se.Code == "" {
+			return codeOf(se.Err, status)
+		}
+		return coded.CodeError()
+	}
+	code := CodeError(strconv.Itoa(status))
+	if _, exists := code.ResponseError(); exists {
+		return code
+	}
+	return ""
+}
+
+// FilterHook returns a hook that only forwards events with one of the codes.
+func FilterHook(hook Hook, codes ...CodeError) Hook {
+	allowed := make(map[CodeError]bool, len(codes))
+	for _, code := range codes {
+		allowed[code] = true
+	}
+	return HookFunc(func(event ErrorEvent) {
+		if allowed[event.Code] {
+			hook.Fire(event)
+		}
+	})
+}
+
+// ThresholdAlert is reported by a ThresholdHook when a code crosses its threshold.
+type ThresholdAlert struct {
+	Code   CodeError     `json:"code"`
+	Count  int           `json:"count"`
+	Window time.Duration `json:"window_ns"`
+	Last   ErrorEvent    `json:"-"`
+}
+
+// ThresholdHook counts error responses per code over a sliding window.
+// Once a code reaches the threshold inside the window, the alert function is
+// called and the count for that code starts again from zero.
+// A threshold below one disables the hook.
+type ThresholdHook struct {
+	threshold int
+	window    time.Duration
+	alert     func(alert ThresholdAlert)
+
+	mu     sync.Mutex
+	events map[CodeError][]time.Time
+}
+
+// NewThresholdHook creates a ThresholdHook calling alert when threshold events
+// for the same code happen within window. A threshold below one disables the hook.
+func NewThresholdHook(threshold int, window time.Duration, alert func(alert ThresholdAlert)) *ThresholdHook {
+	return &ThresholdHook{
+		threshold: threshold,
+		window:    window,
+		alert:     alert,
+		events:    map[CodeError][]time.Time{},
+	}
+}
+
+// Fire records the event and reports an alert when the threshold is reached.
+func (th *ThresholdHook) Fire(event ErrorEvent) {
+	if th.threshold < 1 {
+		return
+	}
+	th.mu.Lock()
+	cutoff := event.Time.Add(-th.window)
+	times := th.events[event.Code]
+	start := 0
+	for start < len(times) && !times[start].After(cutoff) {
+		start++
+	}
+	times = append(times[start:], event.Time)
+
+	count := len(times)
+	if count >= th.threshold {
+		times = nil
+	}
+	th.events[event.Code] = times
+	th.mu.Unlock()
+
+	if count >= th.threshold {
+		th.alert(ThresholdAlert{
+			Code:   event.Code,
+			Count:  count,
+			Window: th.window,
+			Last:   event,
+		})
+	}
+}
+
+// webhookPayload is the JSON document posted by a WebhookHook for an ErrorEvent.
+type webhookPayload struct {
+	Code    string    `json:"code"`
+	Status  int       `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	Method  string    `json:"method,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	TraceID string    `json:"trace_id,omitempty"`
+	SpanID  string    `json:"span_id,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// WebhookHook posts error responses as JSON documents to a URL.
+type WebhookHook struct {
+	URL string
+	// Client defaults to a client with a five second timeout.
+	Client *http.Client
+}
+
+// webhookTimeout bounds the requests of WebhookHooks without a Client.
+const webhookTimeout = 5 * time.Second
+
+// NewWebhookHook creates a WebhookHook posting to url with a five second timeout.
+func NewWebhookHook(url string) *WebhookHook {
+	return &WebhookHook{
+		URL:    url,
+		Client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Fire posts the event to the webhook in the background, so that a slow
+// webhook does not delay the response. Failures are logged.
+func (wh *WebhookHook) Fire(event ErrorEvent) {
+	payload := webhookPayload{
+		Code:    string(event.Code),
+		Status:  event.Status,
+		TraceID: event.Trace.TraceID,
+		SpanID:  event.Trace.SpandID,
+		Time:    event.Time,
+	}
+	if event.Cause != nil {
+		payload.Error = event.Cause.Error()
+	}
+	if event.Request != nil {
+		payload.Method = event.Request.Method
+		payload.Path = event.Request.URL.Path
+	}
+
+	go func() {
+		if err := wh.Send(payload); err != nil {
+			log.Error("failed to send error webhook", zap.Error(err))
+		}
+	}()
+}
+
+// Send posts any JSON payload to the webhook, e.g. a ThresholdAlert, and
+// waits for the response.
+func (wh *WebhookHook) Send(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := wh.Client
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	response, err := client.Post(wh.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
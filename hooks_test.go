@@ -0,0 +1,180 @@
+package tracerlogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/tracer"
+)
+
+func TestThresholdHook(t *testing.T) {
+	var alerts []ThresholdAlert
+	hook := NewThresholdHook(3, time.Minute, func(alert ThresholdAlert) {
+		alerts = append(alerts, alert)
+	})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fire := func(code CodeError, after time.Duration) {
+		hook.Fire(ErrorEvent{Code: code, Time: start.Add(after)})
+	}
+
+	// Events leaving the window are not counted.
+	fire(CodeInternalServerError, 0)
+	fire(CodeInternalServerError, 30*time.Second)
+	fire(CodeInternalServerError, 70*time.Second)
+	if len(alerts) != 0 {
+		t.Fatalf("alerted for events outside the window: %+v", alerts)
+	}
+	// Other codes are counted apart.
+	fire(CodeNotFound, 75*time.Second)
+	fire(CodeInternalServerError, 80*time.Second)
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.Code != CodeInternalServerError || alert.Count != 3 || alert.Window != time.Minute ||
+		!alert.Last.Time.Equal(start.Add(80*time.Second)) {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	// The count starts again from zero after an alert.
+	fire(CodeInternalServerError, 81*time.Second)
+	fire(CodeInternalServerError, 82*time.Second)
+	if len(alerts) != 1 {
+		t.Fatalf("alerted again before the threshold: %+v", alerts)
+	}
+	fire(CodeInternalServerError, 83*time.Second)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(alerts))
+	}
+}
+
+func TestThresholdHookDisabled(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		hook := NewThresholdHook(threshold, time.Minute, func(alert ThresholdAlert) {
+			t.Errorf("threshold %d alerted: %+v", threshold, alert)
+		})
+		hook.Fire(ErrorEvent{Code: CodeNotFound, Time: time.Now()})
+	}
+}
+
+// newWebhookServer records the bodies posted to it, after release is closed.
+func newWebhookServer(t *testing.T, release <-chan struct{}) (*httptest.Server, <-chan []byte) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func TestWebhookHookPayload(t *testing.T) {
+	release := make(chan struct{})
+	server, bodies := newWebhookServer(t, release)
+	hook := NewWebhookHook(server.URL)
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Now()
+	hook.Fire(ErrorEvent{
+		Code:    CodeForbidden,
+		Status:  http.StatusForbidden,
+		Cause:   errors.New("role missing"),
+		Request: httptest.NewRequest(http.MethodDelete, "/orders/42", nil),
+		Trace:   tracer.TraceField{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpandID: "00f067aa0ba902b7"},
+		Time:    at,
+	})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fire waited %v for the webhook", elapsed)
+	}
+	close(release)
+
+	var body []byte
+	select {
+	case body = <-bodies:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not posted")
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid payload %q: %v", body, err)
+	}
+	want := map[string]interface{}{
+		"code":     "403",
+		"status":   float64(403),
+		"error":    "role missing",
+		"method":   "DELETE",
+		"path":     "/orders/42",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"time":     "2024-01-01T12:00:00Z",
+	}
+	if fmt.Sprint(payload) != fmt.Sprint(want) {
+		t.Errorf("payload = %v, want %v", payload, want)
+	}
+}
+
+func TestWebhookHookSend(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	server, bodies := newWebhookServer(t, release)
+
+	alert := ThresholdAlert{Code: CodeInternalServerError, Count: 50, Window: time.Minute}
+	if err := NewWebhookHook(server.URL).Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if body, want := string(<-bodies), `{"code":"500","count":50,"window_ns":60000000000}`; body != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := NewWebhookHook(failing.URL).Send(alert); err == nil {
+		t.Error("Send did not report the failed response")
+	}
+}
+
+func TestRespondWithErrorHookCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		code   CodeError
+	}{
+		{"plain error", http.StatusNotFound, errors.New("no such order"), CodeNotFound},
+		{"no error", http.StatusBadRequest, nil, CodeBadRequest},
+		{"status without code", http.StatusConflict, errors.New("conflict"), ""},
+		{"coded error", http.StatusNotFound, fmt.Errorf("load: %w", CodeForbidden), CodeForbidden},
+		{"stack without code", http.StatusBadRequest, WithStack(errors.New("bad")), CodeBadRequest},
+		{"stack of coded error", http.StatusBadRequest, WithStack(CodeFieldRequired), CodeFieldRequired},
+		{"wrapped", http.StatusInternalServerError, CodeExpiredRequestToken.Wrap(nil), CodeExpiredRequestToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []ErrorEvent
+			unregister := RegisterHook(HookFunc(func(event ErrorEvent) {
+				events = append(events, event)
+			}))
+			defer unregister()
+
+			RespondWithError(httptest.NewRecorder(), tt.status, tt.err)
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			if events[0].Code != tt.code || events[0].Status != tt.status {
+				t.Errorf("event code %q and status %d, want %q and %d", events[0].Code, events[0].Status, tt.code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package tracerlogger
+
+import (
+	"bytes"
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jimxshaw/tracerlogger/tracer"
+)
+
+// defaultHTMLTemplate is the page rendered for error responses sent to browser clients.
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Errors}}<ul>
+{{range .Errors}}<li>{{if .Field}}<strong>{{.Field}}</strong>: {{end}}{{.Message}}</li>
+{{end}}</ul>{{end}}
+<p><small>Error code {{.Code}}{{if .TraceID}} &middot; Trace ID <code>{{.TraceID}}</code>{{end}}</small></p>
+</body>
+</html>
+`
+
+// HTMLPage holds the values available to the HTML error templates.
+type HTMLPage struct {
+	Status     int
+	StatusText string
+	TraceID    string
+	ResponseError
+}
+
+// newHTMLPage creates the HTMLPage for a ResponseError sent in response to the request.
+func newHTMLPage(r *http.Request, re ResponseError, code int) HTMLPage {
+	page := HTMLPage{
+		Status:        code,
+		StatusText:    http.StatusText(code),
+		ResponseError: re,
+	}
+	if page.Title == "" {
+		page.Title = page.StatusText
+	}
+	if tc, ok := tracer.FromCtx(r.Context()); ok {
+		page.TraceID = tc.Sanitize().TraceID
+	}
+	return page
+}
+
+// HTMLRenderer renders ResponseError values as HTML pages.
+// A default template is used unless a template was set for the error code.
+type HTMLRenderer struct {
+	mu        sync.RWMutex
+	fallback  *template.Template
+	templates map[CodeError]*template.Template
+}
+
+// DefaultHTMLRenderer is the renderer used by RespondTo for browser clients.
+var DefaultHTMLRenderer = NewHTMLRenderer()
+
+// NewHTMLRenderer creates a new HTMLRenderer using the default template.
+func NewHTMLRenderer() *HTMLRenderer {
+	return &HTMLRenderer{
+		fallback:  template.Must(template.New("error").Parse(defaultHTMLTemplate)),
+		templates: map[CodeError]*template.Template{},
+	}
+}
+
+// SetDefault replaces the template used for codes without an override.
+func (hr *HTMLRenderer) SetDefault(tmpl *template.Template) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.fallback = tmpl
+}
+
+// SetTemplate overrides the template used for a specific CodeError.
+// A nil template removes the override.
+func (hr *HTMLRenderer) SetTemplate(code CodeError, tmpl *template.Template) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if tmpl == nil {
+		delete(hr.templates, code)
+		return
+	}
+	hr.templates[code] = tmpl
+}
+
+// template returns the template to use for the CodeError.
+func (hr *HTMLRenderer) template(code CodeError) *template.Template {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	if tmpl, exists := hr.templates[code]; exists {
+		return tmpl
+	}
+	return hr.fallback
+}
+
+// Render executes the template for the page and sends it as an HTML response.
+// The template is executed before anything is written, so on error the
+// response is left untouched and the caller can still fall back to JSON.
+func (hr *HTMLRenderer) Render(w http.ResponseWriter, code int, page HTMLPage) error {
+	var buf bytes.Buffer
+	if err := hr.template(page.CodeError()).Execute(&buf, page); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Strict-Transport-Security", strictTransportSecurity)
+	w.WriteHeader(code)
+	buf.WriteTo(w)
+	return nil
+}
+
+// prefersHTML returns true if the request's Accept header ranks text/html above application/json.
+func prefersHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	return acceptQuality(accept, "text/html") > acceptQuality(accept, "application/json")
+}
+
+// acceptQuality returns the quality value the Accept header gives to the media type.
+// The most specific matching range wins, as described in RFC 9110.
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var matchSpecificity int
+		switch rangeType {
+		case mediaType:
+			matchSpecificity = 2
+		case mainType + "/*":
+			matchSpecificity = 1
+		case "*/*":
+			matchSpecificity = 0
+		default:
+			continue
+		}
+		if matchSpecificity < specificity {
+			continue
+		}
+
+		q := 1.0
+		if value, exists := params["q"]; exists {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		quality, specificity = q, matchSpecificity
+	}
+	return quality
+}
@@ -0,0 +1,16 @@
+// Package random provides the random value helpers shared by the tracerlogger packages.
+package random
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Hex generates a random hex value from n random bytes.
+func Hex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
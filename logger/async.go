@@ -0,0 +1,371 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// OverflowPolicy decides what an AsyncQueue does with an entry when it is full.
+type OverflowPolicy int
+
+const (
+	// Block waits until the queue has room.
+	Block OverflowPolicy = iota
+	// DropOldest drops the oldest queued entry to make room.
+	DropOldest
+	// DropNewest drops the entry being logged.
+	DropNewest
+	// DropBelowLevel drops the entry being logged if it is below DropLevel,
+	// and waits until the queue has room otherwise.
+	DropBelowLevel
+)
+
+// AsyncConfig configures an AsyncQueue.
+type AsyncConfig struct {
+	// Size is the number of entries the queue holds. It defaults to 4096.
+	Size int
+	// Overflow is the policy applied when the queue is full.
+	Overflow OverflowPolicy
+	// DropLevel is the level below which DropBelowLevel drops entries.
+	DropLevel zapcore.Level
+	// FlushInterval is how often the outputs are synced. It defaults to a second.
+	FlushInterval time.Duration
+	// DrainTimeout bounds how long Sync, and so Cleanup, waits for the queue
+	// to be written. It defaults to five seconds.
+	DrainTimeout time.Duration
+}
+
+// AsyncStats are the counters of an AsyncQueue.
+type AsyncStats struct {
+	Queued  int    `json:"queued"`
+	Written uint64 `json:"written"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// errQueueClosed is returned when writing to the queue after Close.
+var errQueueClosed = errors.New("async log queue closed")
+
+// asyncEntry is a queued entry with the core, or the checked entry of the
+// core, it is written to.
+type asyncEntry struct {
+	core    zapcore.Core
+	checked *zapcore.CheckedEntry
+	entry   zapcore.Entry
+	fields  []zapcore.Field
+}
+
+// AsyncQueue writes log entries from a background goroutine, so that logging
+// does not wait on slow outputs. Entries above Error level, which exit or
+// panic once written, are written synchronously after the queued ones.
+type AsyncQueue struct {
+	cfg AsyncConfig
+
+	mu      sync.Mutex
+	space   *sync.Cond
+	entries []asyncEntry
+	head    int
+	count   int
+	writing int
+	roots   []zapcore.Core
+	err     error
+	closed  bool
+
+	wake chan struct{}
+	done chan struct{}
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewAsyncQueue creates an AsyncQueue and starts its writer.
+// Pass it to New with WithAsync and Close it when done.
+func NewAsyncQueue(cfg AsyncConfig) *AsyncQueue {
+	if cfg.Size <= 0 {
+		cfg.Size = 4096
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 5 * time.Second
+	}
+	q := &AsyncQueue{
+		cfg:     cfg,
+		entries: make([]asyncEntry, cfg.Size),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	q.space = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// WithAsync writes the entries of the Logger through the queue.
+func WithAsync(q *AsyncQueue) Option {
+	return WithZapOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		q.mu.Lock()
+		q.roots = append(q.roots, core)
+		q.mu.Unlock()
+		return &asyncCore{Core: core, queue: q}
+	}))
+}
+
+// Stats returns the counters of the queue.
+func (q *AsyncQueue) Stats() AsyncStats {
+	q.mu.Lock()
+	queued := q.count
+	q.mu.Unlock()
+	return AsyncStats{
+		Queued:  queued,
+		Written: q.written.Load(),
+		Dropped: q.dropped.Load(),
+	}
+}
+
+// Drain waits until every queued entry is written or the context is done.
+func (q *AsyncQueue) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		q.mu.Lock()
+		pending := q.count + q.writing
+		q.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+		q.notify()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("async log queue: %d entries not written: %w", pending, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close drains the queue until the context is done and stops the writer.
+// Entries logged afterwards are written synchronously.
+func (q *AsyncQueue) Close(ctx context.Context) error {
+	err := q.Drain(ctx)
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return err
+	}
+	q.closed = true
+	q.space.Broadcast()
+	q.mu.Unlock()
+	close(q.done)
+	return errors.Join(err, q.sync())
+}
+
+// enqueue adds an entry, applying the overflow policy when the queue is full.
+func (q *AsyncQueue) enqueue(item asyncEntry) error {
+	q.mu.Lock()
+	for q.count == len(q.entries) && !q.closed {
+		switch {
+		case q.cfg.Overflow == DropOldest:
+			q.entries[q.head] = asyncEntry{}
+			q.head = (q.head + 1) % len(q.entries)
+			q.count--
+			q.dropped.Add(1)
+		case q.cfg.Overflow == DropNewest,
+			q.cfg.Overflow == DropBelowLevel && item.entry.Level < q.cfg.DropLevel:
+			q.mu.Unlock()
+			q.dropped.Add(1)
+			return nil
+		default:
+			q.notify()
+			q.space.Wait()
+		}
+	}
+	if q.closed {
+		q.mu.Unlock()
+		return errQueueClosed
+	}
+	q.entries[(q.head+q.count)%len(q.entries)] = item
+	q.count++
+	q.mu.Unlock()
+	q.notify()
+	return nil
+}
+
+// notify wakes the writer.
+func (q *AsyncQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the queued entries and syncs the outputs periodically.
+func (q *AsyncQueue) run() {
+	ticker := time.NewTicker(q.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.wake:
+			q.write()
+		case <-ticker.C:
+			q.write()
+			if err := q.sync(); err != nil {
+				q.fail(err)
+			}
+		}
+	}
+}
+
+// write writes the entries queued so far.
+func (q *AsyncQueue) write() {
+	for {
+		q.mu.Lock()
+		if q.count == 0 {
+			q.mu.Unlock()
+			return
+		}
+		batch := make([]asyncEntry, q.count)
+		for i := range batch {
+			index := (q.head + i) % len(q.entries)
+			batch[i] = q.entries[index]
+			q.entries[index] = asyncEntry{}
+		}
+		q.head = (q.head + q.count) % len(q.entries)
+		q.writing, q.count = q.count, 0
+		q.space.Broadcast()
+		q.mu.Unlock()
+
+		for _, item := range batch {
+			if item.checked != nil {
+				// Errors are reported to the ErrorOutput of the Logger.
+				item.checked.Write(item.fields...)
+			} else if err := item.core.Write(item.entry, item.fields); err != nil {
+				q.fail(err)
+			}
+			q.written.Add(1)
+		}
+
+		q.mu.Lock()
+		q.writing = 0
+		q.mu.Unlock()
+	}
+}
+
+// sync syncs the outputs of every core written through the queue.
+func (q *AsyncQueue) sync() error {
+	q.mu.Lock()
+	roots := q.roots
+	q.mu.Unlock()
+	var errs []error
+	for _, core := range roots {
+		if err := core.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fail records a background error, returned by the next Sync.
+func (q *AsyncQueue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// takeErr returns and clears the background error.
+func (q *AsyncQueue) takeErr() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.err
+	q.err = nil
+	return err
+}
+
+// asyncCore queues the entries of a core on an AsyncQueue.
+type asyncCore struct {
+	zapcore.Core
+	queue *AsyncQueue
+}
+
+func (ac *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &asyncCore{Core: ac.Core.With(fields), queue: ac.queue}
+}
+
+func (ac *asyncCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !ac.Enabled(entry.Level) {
+		return checked
+	}
+	// The cores inside the queue sample and filter the entry now, and the
+	// entry they accepted is written later by the writer.
+	inner := ac.Core.Check(entry, nil)
+	if inner == nil {
+		return checked
+	}
+	qe := &queuedEntry{asyncCore: ac, inner: inner}
+	checked = checked.AddCore(entry, qe)
+	qe.outer = checked
+	return checked
+}
+
+func (ac *asyncCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level > zapcore.ErrorLevel {
+		return ac.writeNow(func() error { return ac.Core.Write(entry, fields) })
+	}
+	item := asyncEntry{core: ac.Core, entry: entry, fields: freezeFields(fields)}
+	if err := ac.queue.enqueue(item); err == errQueueClosed {
+		return ac.Core.Write(entry, fields)
+	}
+	return nil
+}
+
+// writeNow writes an entry after the queued ones, before the process exits or panics.
+func (ac *asyncCore) writeNow(write func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ac.queue.cfg.DrainTimeout)
+	defer cancel()
+	drainErr := ac.queue.Drain(ctx)
+	return errors.Join(drainErr, write(), ac.Core.Sync())
+}
+
+// queuedEntry queues an entry checked by an asyncCore, to be written through
+// the entry checked by the core it wraps. The fields are encoded before they
+// are queued, since the caller may change them once the log call returns.
+type queuedEntry struct {
+	*asyncCore
+	inner *zapcore.CheckedEntry
+	outer *zapcore.CheckedEntry
+}
+
+func (qe *queuedEntry) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// The caller and stack are added to the outer entry after Check.
+	qe.inner.Entry = entry
+	qe.inner.ErrorOutput = qe.outer.ErrorOutput
+	if entry.Level > zapcore.ErrorLevel {
+		return qe.writeNow(func() error {
+			qe.inner.Write(fields...)
+			return nil
+		})
+	}
+	item := asyncEntry{checked: qe.inner, entry: entry, fields: freezeFields(fields)}
+	if err := qe.queue.enqueue(item); err == errQueueClosed {
+		qe.inner.Write(fields...)
+	}
+	return nil
+}
+
+// Sync waits up to the DrainTimeout for the queued entries to be written and syncs the outputs.
+func (ac *asyncCore) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ac.queue.cfg.DrainTimeout)
+	defer cancel()
+	drainErr := ac.queue.Drain(ctx)
+	return errors.Join(drainErr, ac.queue.takeErr(), ac.queue.sync())
+}
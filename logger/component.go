@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// allLevels is the level of the cores wrapped by a componentCore,
+// which does the level filtering itself.
+const allLevels = zapcore.Level(math.MinInt8)
+
+// defaultComponent is the component name matching every unconfigured logger.
+const defaultComponent = "*"
+
+// ComponentLevels holds the levels of named loggers, e.g. "db=debug,http=info,*=warn".
+//
+// Names are hierarchical: a logger named "db.postgres" uses the level of
+// "db.postgres", else of "db", else the default level "*". The default level
+// is the level of the Logger, so it is shared with its LevelController.
+type ComponentLevels struct {
+	Identity
+
+	base zap.AtomicLevel
+
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+
+	// current is a copy of levels replaced on every update, read without locking.
+	current  atomic.Pointer[map[string]zapcore.Level]
+	minLevel atomic.Int32
+}
+
+// NewComponentLevels creates ComponentLevels for loggers whose default level is base.
+// The spec is a comma separated list of component=level pairs, e.g. the value
+// of a LOG_LEVELS environment variable.
+func NewComponentLevels(base zap.AtomicLevel, spec string) (*ComponentLevels, error) {
+	cl := &ComponentLevels{
+		base:   base,
+		levels: map[string]zapcore.Level{},
+	}
+	if err := cl.Set(spec); err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+// ParseComponentLevels parses a component=level list.
+func ParseComponentLevels(spec string) (map[string]zapcore.Level, error) {
+	levels := map[string]zapcore.Level{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		component, text, found := strings.Cut(pair, "=")
+		component = strings.TrimSpace(component)
+		if !found || component == "" {
+			return nil, fmt.Errorf("invalid component level %q, expected component=level", pair)
+		}
+		level, err := ParseLevel(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("component %q: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// ParseLevel parses a level name such as "trace", "debug" or "WARN".
+func ParseLevel(text string) (zapcore.Level, error) {
+	if strings.EqualFold(text, "trace") {
+		return TraceLevel, nil
+	}
+	var level zapcore.Level
+	err := level.UnmarshalText([]byte(text))
+	return level, err
+}
+
+// Set replaces every component level with the spec.
+// A "*" entry sets the default level.
+func (cl *ComponentLevels) Set(spec string) error {
+	levels, err := ParseComponentLevels(spec)
+	if err != nil {
+		return err
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if level, exists := levels[defaultComponent]; exists {
+		cl.base.SetLevel(level)
+		delete(levels, defaultComponent)
+	}
+	cl.levels = levels
+	cl.updated()
+	return nil
+}
+
+// SetLevel sets the level of a single component.
+func (cl *ComponentLevels) SetLevel(component string, level zapcore.Level) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if component == defaultComponent {
+		cl.base.SetLevel(level)
+		return
+	}
+	cl.levels[component] = level
+	cl.updated()
+}
+
+// Unset removes the level of a component, which then inherits its parent's level.
+func (cl *ComponentLevels) Unset(component string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	delete(cl.levels, component)
+	cl.updated()
+}
+
+// updated publishes the levels to Level. It must be called with the lock held.
+func (cl *ComponentLevels) updated() {
+	current := make(map[string]zapcore.Level, len(cl.levels))
+	min := zapcore.FatalLevel
+	for component, level := range cl.levels {
+		current[component] = level
+		if level < min {
+			min = level
+		}
+	}
+	cl.current.Store(&current)
+	cl.minLevel.Store(int32(min))
+}
+
+// Level returns the level of the logger name. It is resolved on every call,
+// so that the many names of dynamic loggers are not kept.
+func (cl *ComponentLevels) Level(name string) zapcore.Level {
+	if name == "" {
+		return cl.base.Level()
+	}
+	levels := *cl.current.Load()
+	for component := name; ; {
+		if level, exists := levels[component]; exists {
+			return level
+		}
+		index := strings.LastIndexByte(component, '.')
+		if index < 0 {
+			return cl.base.Level()
+		}
+		component = component[:index]
+	}
+}
+
+// Enabled returns true if a logger with any name could log at the level.
+func (cl *ComponentLevels) Enabled(level zapcore.Level) bool {
+	return level >= cl.base.Level() || level >= zapcore.Level(cl.minLevel.Load())
+}
+
+// String returns the levels as a component=level list, starting with the default level.
+func (cl *ComponentLevels) String() string {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	pairs := make([]string, 0, len(cl.levels))
+	for component, level := range cl.levels {
+		pairs = append(pairs, component+"="+LevelName(level))
+	}
+	sort.Strings(pairs)
+	return strings.Join(append([]string{defaultComponent + "=" + LevelName(cl.base.Level())}, pairs...), ",")
+}
+
+// ServeHTTP serves the levels as a component=level list on GET and replaces them with the body of a PUT.
+func (cl *ComponentLevels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+		if err == nil {
+			err = cl.Set(string(body))
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err)
+			return
+		}
+		Default().Info("component log levels changed", zap.Stringer("levels", cl), zap.String("by", cl.requester(r)))
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	fmt.Fprintln(w, cl.String())
+}
+
+// WithComponentLevels filters the entries of the Logger by the level of the logger name.
+// The levels must have been created with the Level of the config passed to New.
+func WithComponentLevels(levels *ComponentLevels) Option {
+	return func(o *options) {
+		o.componentLevels = levels
+	}
+}
+
+// componentCore filters the entries of a core by the level of their logger name.
+type componentCore struct {
+	zapcore.Core
+	levels *ComponentLevels
+}
+
+func (cc *componentCore) Enabled(level zapcore.Level) bool {
+	return cc.levels.Enabled(level)
+}
+
+func (cc *componentCore) With(fields []zapcore.Field) zapcore.Core {
+	return &componentCore{Core: cc.Core.With(fields), levels: cc.levels}
+}
+
+func (cc *componentCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < cc.levels.Level(entry.LoggerName) {
+		return checked
+	}
+	return cc.Core.Check(entry, checked)
+}
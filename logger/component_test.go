@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestComponentLevelsLevel(t *testing.T) {
+	base := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	levels, err := NewComponentLevels(base, "db=debug, db.postgres=error, http=warn")
+	if err != nil {
+		t.Fatalf("NewComponentLevels: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		level zapcore.Level
+	}{
+		{"", zapcore.InfoLevel},
+		{"db", zapcore.DebugLevel},
+		{"db.mysql", zapcore.DebugLevel},
+		{"db.postgres", zapcore.ErrorLevel},
+		{"db.postgres.pool", zapcore.ErrorLevel},
+		{"dbx", zapcore.InfoLevel},
+		{"http.client", zapcore.WarnLevel},
+		{"cache", zapcore.InfoLevel},
+	}
+	check := func() {
+		t.Helper()
+		for _, tt := range tests {
+			if level := levels.Level(tt.name); level != tt.level {
+				t.Errorf("Level(%q) = %v, want %v", tt.name, level, tt.level)
+			}
+		}
+	}
+	check()
+
+	// Updates apply to the names resolved before.
+	levels.SetLevel("cache", TraceLevel)
+	levels.Unset("db.postgres")
+	levels.SetLevel("*", zapcore.WarnLevel)
+	tests[0].level, tests[3].level, tests[4].level = zapcore.WarnLevel, zapcore.DebugLevel, zapcore.DebugLevel
+	tests[5].level, tests[7].level = zapcore.WarnLevel, TraceLevel
+	check()
+	if spec := levels.String(); spec != "*=warn,cache=trace,db=debug,http=warn" {
+		t.Errorf("String() = %q", spec)
+	}
+	if !levels.Enabled(TraceLevel) || levels.Enabled(TraceLevel-1) {
+		t.Error("Enabled does not follow the lowest component level")
+	}
+}
+
+func TestComponentLevelsFilterTees(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	cfg.OutputPaths = nil
+	levels, err := NewComponentLevels(cfg.Level, "db=debug")
+	if err != nil {
+		t.Fatalf("NewComponentLevels: %v", err)
+	}
+	ring := NewRingBuffer(RingBufferConfig{Size: 10})
+	l, err := New(cfg, WithComponentLevels(levels), WithRingBuffer(ring))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Named("db").Debug("query")
+	l.Named("http").Debug("request")
+	l.Named("http").Info("served")
+	l.Debug("unnamed")
+
+	var messages []string
+	for _, entry := range ring.Entries(RecentFilter{}) {
+		messages = append(messages, entry.Logger+":"+entry.Message)
+	}
+	if len(messages) != 2 || messages[0] != "db:query" || messages[1] != "http:served" {
+		t.Errorf("the tee got %q, want the db debug and http info entries", messages)
+	}
+}
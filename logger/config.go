@@ -0,0 +1,346 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvConfig      = "LOG_CONFIG"
+	EnvPreset      = "LOG_PRESET"
+	EnvSchema      = "LOG_SCHEMA"
+	EnvLevel       = "LOG_LEVEL"
+	EnvLevels      = "LOG_LEVELS"
+	EnvFormat      = "LOG_FORMAT"
+	EnvOutput      = "LOG_OUTPUT"
+	EnvErrorOutput = "LOG_ERROR_OUTPUT"
+	EnvSampling    = "LOG_SAMPLING"
+	EnvCaller      = "LOG_CALLER"
+	EnvStacktrace  = "LOG_STACKTRACE"
+	EnvErrorStack  = "LOG_ERROR_STACK"
+)
+
+// encodings are the values accepted for the log format.
+var encodings = map[string]bool{
+	"console":   true,
+	"json":      true,
+	DevEncoding: true,
+}
+
+// Config is the logger configuration read from files and environment variables.
+// Empty fields keep the value of the configuration they are applied to.
+type Config struct {
+	// Preset replaces the base config, "development", "production" or "test".
+	Preset string `json:"preset,omitempty" yaml:"preset,omitempty"`
+	// Schema names the fields for a log platform, "ecs", "datadog" or "gcp:PROJECT_ID".
+	Schema string `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Level is the default level, e.g. "info".
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Levels are the per-component levels, e.g. "db=debug,http=info".
+	Levels string `json:"levels,omitempty" yaml:"levels,omitempty"`
+	// Format is the encoding, "console", "json" or "dev".
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Output are the paths or URLs the logs are written to.
+	Output []string `json:"output,omitempty" yaml:"output,omitempty"`
+	// ErrorOutput are the paths or URLs internal logger errors are written to.
+	ErrorOutput []string `json:"error_output,omitempty" yaml:"error_output,omitempty"`
+	// Sampling is "initial,thereafter" per second, or "off".
+	Sampling string `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Caller adds the calling file and line to the logs.
+	Caller *bool `json:"caller,omitempty" yaml:"caller,omitempty"`
+	// Stacktrace is the level from which stack traces are captured, or "off".
+	Stacktrace string `json:"stacktrace,omitempty" yaml:"stacktrace,omitempty"`
+	// ErrorStack is the level from which the stacks of logged errors are added, or "off".
+	ErrorStack string `json:"error_stack,omitempty" yaml:"error_stack,omitempty"`
+}
+
+// LoadConfig reads a Config from a YAML or JSON file, chosen by the file extension.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&cfg)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ConfigFromEnv reads a Config from the LOG_* environment variables.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Preset:      os.Getenv(EnvPreset),
+		Schema:      os.Getenv(EnvSchema),
+		Level:       os.Getenv(EnvLevel),
+		Levels:      os.Getenv(EnvLevels),
+		Format:      os.Getenv(EnvFormat),
+		Output:      splitList(os.Getenv(EnvOutput)),
+		ErrorOutput: splitList(os.Getenv(EnvErrorOutput)),
+		Sampling:    os.Getenv(EnvSampling),
+		Stacktrace:  os.Getenv(EnvStacktrace),
+		ErrorStack:  os.Getenv(EnvErrorStack),
+	}
+
+	var errs []error
+	if value := os.Getenv(EnvCaller); value != "" {
+		caller, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("caller: invalid boolean %q", value))
+		}
+		cfg.Caller = &caller
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return cfg, fmt.Errorf("environment: %w", err)
+	}
+	return cfg, nil
+}
+
+// Merge returns the Config with the non-empty fields of override applied.
+func (c Config) Merge(override Config) Config {
+	if override.Preset != "" {
+		c.Preset = override.Preset
+	}
+	if override.Schema != "" {
+		c.Schema = override.Schema
+	}
+	if override.Level != "" {
+		c.Level = override.Level
+	}
+	if override.Levels != "" {
+		c.Levels = override.Levels
+	}
+	if override.Format != "" {
+		c.Format = override.Format
+	}
+	if len(override.Output) > 0 {
+		c.Output = override.Output
+	}
+	if len(override.ErrorOutput) > 0 {
+		c.ErrorOutput = override.ErrorOutput
+	}
+	if override.Sampling != "" {
+		c.Sampling = override.Sampling
+	}
+	if override.Caller != nil {
+		c.Caller = override.Caller
+	}
+	if override.Stacktrace != "" {
+		c.Stacktrace = override.Stacktrace
+	}
+	if override.ErrorStack != "" {
+		c.ErrorStack = override.ErrorStack
+	}
+	return c
+}
+
+// Validate checks every field and reports all problems together.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Preset != "" {
+		if _, err := Preset(c.Preset); err != nil {
+			errs = append(errs, fmt.Errorf("preset: %w", err))
+		}
+	}
+	if c.Schema != "" {
+		if _, err := SchemaByName(c.Schema); err != nil {
+			errs = append(errs, fmt.Errorf("schema: %w", err))
+		}
+	}
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			errs = append(errs, fmt.Errorf("level: %w", err))
+		}
+	}
+	if c.Levels != "" {
+		if _, err := ParseComponentLevels(c.Levels); err != nil {
+			errs = append(errs, fmt.Errorf("levels: %w", err))
+		}
+	}
+	if c.Format != "" && !encodings[c.Format] {
+		errs = append(errs, fmt.Errorf("format: unknown format %q", c.Format))
+	}
+	if c.Sampling != "" {
+		if _, err := parseSampling(c.Sampling); err != nil {
+			errs = append(errs, fmt.Errorf("sampling: %w", err))
+		}
+	}
+	if c.Stacktrace != "" && c.Stacktrace != "off" {
+		if _, err := ParseLevel(c.Stacktrace); err != nil {
+			errs = append(errs, fmt.Errorf("stacktrace: %w", err))
+		}
+	}
+	if c.ErrorStack != "" && c.ErrorStack != "off" {
+		if _, err := ParseLevel(c.ErrorStack); err != nil {
+			errs = append(errs, fmt.Errorf("error_stack: %w", err))
+		}
+	}
+	for _, outputs := range [][]string{c.Output, c.ErrorOutput} {
+		for _, output := range outputs {
+			if strings.TrimSpace(output) == "" {
+				errs = append(errs, errors.New("output: empty path"))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Build applies the Config to a copy of base, or of the preset if one is set.
+// The returned options must be passed to New together with the zap config.
+func (c Config) Build(base zap.Config) (zap.Config, []Option, error) {
+	if err := c.Validate(); err != nil {
+		return base, nil, err
+	}
+
+	cfg := base
+	if c.Preset != "" {
+		cfg, _ = Preset(c.Preset)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(cfg.Level.Level())
+	if c.Level != "" {
+		level, _ := ParseLevel(c.Level)
+		cfg.Level.SetLevel(level)
+	}
+	if c.Format != "" {
+		cfg.Encoding = c.Format
+	}
+	if len(c.Output) > 0 {
+		cfg.OutputPaths = c.Output
+	}
+	if len(c.ErrorOutput) > 0 {
+		cfg.ErrorOutputPaths = c.ErrorOutput
+	}
+	if c.Sampling != "" {
+		cfg.Sampling, _ = parseSampling(c.Sampling)
+	}
+	if c.Caller != nil {
+		cfg.DisableCaller = !*c.Caller
+	}
+
+	var opts []Option
+	if c.Schema != "" {
+		schema, _ := SchemaByName(c.Schema)
+		opts = append(opts, WithSchema(schema))
+	}
+	if c.Stacktrace != "" {
+		cfg.DisableStacktrace = true
+		if c.Stacktrace != "off" {
+			level, _ := ParseLevel(c.Stacktrace)
+			opts = append(opts, WithZapOptions(zap.AddStacktrace(level)))
+		}
+	}
+	if c.ErrorStack == "off" {
+		opts = append(opts, WithErrorStacks(nil))
+	} else if c.ErrorStack != "" {
+		level, _ := ParseLevel(c.ErrorStack)
+		opts = append(opts, WithErrorStacks(level))
+	}
+	if c.Levels != "" {
+		levels, err := NewComponentLevels(cfg.Level, c.Levels)
+		if err != nil {
+			return base, nil, err
+		}
+		opts = append(opts, WithComponentLevels(levels))
+	}
+	return cfg, opts, nil
+}
+
+// Configure builds the default Logger, used by the plain and the trace-aware
+// package functions. Settings are applied in increasing precedence:
+//
+//  1. DefaultConfig, or the preset named in the file or LOG_PRESET
+//  2. the config file at path, or at LOG_CONFIG when path is empty
+//  3. the LOG_* environment variables
+//  4. the options passed in code
+//
+// Every invalid setting is reported in the returned error, and the default
+// Logger is left unchanged.
+func Configure(path string, opts ...Option) error {
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+
+	var cfg Config
+	if path != "" {
+		fileConfig, err := LoadConfig(path)
+		if err != nil {
+			return fmt.Errorf("logger config: %w", err)
+		}
+		cfg = fileConfig
+	}
+
+	envConfig, err := ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("logger config: %w", err)
+	}
+	cfg = cfg.Merge(envConfig)
+
+	zapConfig, configOpts, err := cfg.Build(DefaultConfig)
+	if err != nil {
+		return fmt.Errorf("logger config: %w", err)
+	}
+	l, err := New(zapConfig, append(configOpts, opts...)...)
+	if err != nil {
+		return fmt.Errorf("logger config: %w", err)
+	}
+	SetDefault(l)
+	return nil
+}
+
+// parseSampling parses "initial,thereafter" or "off".
+func parseSampling(value string) (*zap.SamplingConfig, error) {
+	if value == "off" {
+		return nil, nil
+	}
+	initial, thereafter, found := strings.Cut(value, ",")
+	if !found {
+		return nil, fmt.Errorf("invalid sampling %q, expected initial,thereafter or off", value)
+	}
+	sampling := &zap.SamplingConfig{}
+	var err error
+	if sampling.Initial, err = strconv.Atoi(strings.TrimSpace(initial)); err != nil {
+		return nil, fmt.Errorf("invalid sampling initial %q", initial)
+	}
+	if sampling.Thereafter, err = strconv.Atoi(strings.TrimSpace(thereafter)); err != nil {
+		return nil, fmt.Errorf("invalid sampling thereafter %q", thereafter)
+	}
+	return sampling, nil
+}
+
+// splitList splits a comma separated list, ignoring empty entries.
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ConnStats counts the connection state changes of an http.Server.
+type ConnStats struct {
+	// Open is the number of connections that are neither closed nor hijacked.
+	Open     int64 `json:"open"`
+	New      int64 `json:"new"`
+	Active   int64 `json:"active"`
+	Idle     int64 `json:"idle"`
+	Hijacked int64 `json:"hijacked"`
+	Closed   int64 `json:"closed"`
+}
+
+// ConnStateLogger logs the connection lifecycle of an http.Server and counts
+// the state changes.
+// srv := &http.Server{ConnState: log.NewConnStateLogger(log.Default(), zapcore.DebugLevel).ConnState}
+type ConnStateLogger struct {
+	logger *Logger
+	level  zapcore.Level
+
+	open, new, active, idle, hijacked, closed atomic.Int64
+}
+
+// NewConnStateLogger creates a ConnStateLogger logging at the level with a
+// logger named "http.conn".
+func NewConnStateLogger(l *Logger, level zapcore.Level) *ConnStateLogger {
+	return &ConnStateLogger{logger: l.Named("http.conn"), level: level}
+}
+
+// ConnState is the http.Server ConnState hook.
+func (csl *ConnStateLogger) ConnState(conn net.Conn, state http.ConnState) {
+	open := csl.open.Load()
+	switch state {
+	case http.StateNew:
+		csl.new.Add(1)
+		open = csl.open.Add(1)
+	case http.StateActive:
+		csl.active.Add(1)
+	case http.StateIdle:
+		csl.idle.Add(1)
+	case http.StateHijacked:
+		csl.hijacked.Add(1)
+		open = csl.open.Add(-1)
+	case http.StateClosed:
+		csl.closed.Add(1)
+		open = csl.open.Add(-1)
+	}
+
+	if checked := csl.logger.zap.Check(csl.level, "connection "+state.String()); checked != nil {
+		checked.Write(
+			zap.Stringer("remote_addr", conn.RemoteAddr()),
+			zap.Stringer("local_addr", conn.LocalAddr()),
+			zap.Stringer("state", state),
+			zap.Int64("open", open),
+		)
+	}
+}
+
+// Stats returns the counters.
+func (csl *ConnStateLogger) Stats() ConnStats {
+	return ConnStats{
+		Open:     csl.open.Load(),
+		New:      csl.new.Load(),
+		Active:   csl.active.Load(),
+		Idle:     csl.idle.Load(),
+		Hijacked: csl.hijacked.Load(),
+		Closed:   csl.closed.Load(),
+	}
+}
@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DedupConfig configures the collapsing of repeated entries of WithDedup.
+type DedupConfig struct {
+	// Window is the longest time covered by a summary. It defaults to 10 seconds.
+	Window time.Duration
+	// Idle ends a burst when no repeat was logged for this long. It defaults
+	// to a second, and is at most Window.
+	Idle time.Duration
+	// Fields are the keys of the fields telling entries apart, next to the
+	// level, logger name and message. The other fields are ignored.
+	Fields []string
+}
+
+// WithDedup collapses repeated entries with the same level, logger name,
+// message and selected fields. The first entry is logged immediately and
+// the repeats are counted. When the burst ends or the window closes, the
+// message is logged again with the selected fields, the number of repeats
+// under "repeated" and the time from the first to the last repeat under
+// "repeat_span". Sync logs the summaries of the open bursts.
+// Entries at DPanic level and above are never collapsed.
+func WithDedup(cfg DedupConfig) Option {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Idle <= 0 {
+		cfg.Idle = time.Second
+	}
+	if cfg.Idle > cfg.Window {
+		cfg.Idle = cfg.Window
+	}
+	selected := make(map[string]bool, len(cfg.Fields))
+	for _, key := range cfg.Fields {
+		selected[key] = true
+	}
+	return WithZapOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		state := &dedupState{cfg: cfg, selected: selected, bursts: map[string]*dedupBurst{}}
+		return &dedupCore{Core: core, state: state}
+	}))
+}
+
+// dedupState is shared by a dedupCore and the cores created with With.
+type dedupState struct {
+	cfg      DedupConfig
+	selected map[string]bool
+
+	mu     sync.Mutex
+	bursts map[string]*dedupBurst
+}
+
+// dedupBurst is a repeated entry.
+type dedupBurst struct {
+	core    *dedupCore
+	entry   zapcore.Entry
+	fields  []zapcore.Field
+	first   time.Time
+	last    time.Time
+	repeats int
+	timer   *time.Timer
+}
+
+// dedupCore collapses repeated entries of a core.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// context are the selected fields added with With, encoded for the key.
+	context []string
+}
+
+func (dc *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	_, encoded := dc.state.selectFields(fields)
+	context := make([]string, 0, len(dc.context)+len(encoded))
+	context = append(context, dc.context...)
+	return &dedupCore{Core: dc.Core.With(fields), state: dc.state, context: append(context, encoded...)}
+}
+
+func (dc *dedupCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !dc.Enabled(entry.Level) {
+		return checked
+	}
+	if entry.Level >= zapcore.DPanicLevel {
+		return dc.Core.Check(entry, checked)
+	}
+
+	// Whether the entry repeats depends on its fields, which are only known
+	// in Write, so the entry is checked against the core now and written
+	// through the inner checked entry only if it is not a repeat.
+	inner := dc.Core.Check(entry, nil)
+	if inner == nil {
+		return checked
+	}
+	de := &dedupEntry{dedupCore: dc, inner: inner}
+	checked = checked.AddCore(entry, de)
+	de.outer = checked
+	return checked
+}
+
+func (dc *dedupCore) Sync() error {
+	dc.state.mu.Lock()
+	bursts := make([]*dedupBurst, 0, len(dc.state.bursts))
+	for key, burst := range dc.state.bursts {
+		burst.timer.Stop()
+		delete(dc.state.bursts, key)
+		bursts = append(bursts, burst)
+	}
+	dc.state.mu.Unlock()
+
+	for _, burst := range bursts {
+		burst.summarize()
+	}
+	return dc.Core.Sync()
+}
+
+// dedupEntry writes an entry checked by a dedupCore unless it repeats.
+type dedupEntry struct {
+	*dedupCore
+	inner *zapcore.CheckedEntry
+	outer *zapcore.CheckedEntry
+}
+
+func (de *dedupEntry) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !de.state.first(de.dedupCore, entry, fields) {
+		return nil
+	}
+	// The caller and stack are added to the outer entry after Check.
+	de.inner.Entry = entry
+	de.inner.ErrorOutput = de.outer.ErrorOutput
+	de.inner.Write(fields...)
+	return nil
+}
+
+// selectFields returns the selected fields and their encoded values.
+func (ds *dedupState) selectFields(fields []zapcore.Field) ([]zapcore.Field, []string) {
+	if len(ds.selected) == 0 {
+		return nil, nil
+	}
+	var selected []zapcore.Field
+	var encoded []string
+	for _, field := range fields {
+		if !ds.selected[field.Key] {
+			continue
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		selected = append(selected, field)
+		encoded = append(encoded, field.Key+"="+fmt.Sprint(enc.Fields[field.Key]))
+	}
+	return selected, encoded
+}
+
+// first returns true if the entry starts a burst, and counts it otherwise.
+func (ds *dedupState) first(core *dedupCore, entry zapcore.Entry, fields []zapcore.Field) bool {
+	selected, encoded := ds.selectFields(fields)
+	key := strings.Join(append([]string{
+		entry.Level.String(), entry.LoggerName, entry.Message,
+	}, append(core.context[:len(core.context):len(core.context)], encoded...)...), "\x00")
+	now := time.Now()
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if burst, exists := ds.bursts[key]; exists {
+		burst.repeats++
+		burst.last = now
+		wait := ds.cfg.Idle
+		if closes := burst.first.Add(ds.cfg.Window).Sub(now); closes < wait {
+			wait = closes
+		}
+		burst.timer.Reset(wait)
+		return false
+	}
+
+	burst := &dedupBurst{core: core, entry: entry, fields: selected, first: now, last: now}
+	burst.timer = time.AfterFunc(ds.cfg.Idle, func() {
+		ds.end(key, burst)
+	})
+	ds.bursts[key] = burst
+	return true
+}
+
+// end closes a burst and logs its summary.
+func (ds *dedupState) end(key string, burst *dedupBurst) {
+	ds.mu.Lock()
+	if ds.bursts[key] != burst {
+		ds.mu.Unlock()
+		return
+	}
+	delete(ds.bursts, key)
+	ds.mu.Unlock()
+	burst.summarize()
+}
+
+// summarize logs the number of repeats of the burst, if any.
+func (db *dedupBurst) summarize() {
+	if db.repeats == 0 {
+		return
+	}
+	entry := db.entry
+	entry.Time = time.Now()
+	entry.Stack = ""
+	checked := db.core.Core.Check(entry, nil)
+	if checked == nil {
+		return
+	}
+	fields := append(db.fields[:len(db.fields):len(db.fields)],
+		zap.Int("repeated", db.repeats),
+		zap.Duration("repeat_span", db.last.Sub(db.first)),
+	)
+	checked.Write(fields...)
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newDedupLogger creates a zap logger collapsing repeats into an observer core.
+func newDedupLogger(cfg DedupConfig) (*zap.Logger, *observer.ObservedLogs) {
+	var o options
+	WithDedup(cfg)(&o)
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(core, o.zapOptions...), logs
+}
+
+// summaries returns the summaries of the bursts logged so far.
+func summaries(logs *observer.ObservedLogs) []observer.LoggedEntry {
+	return logs.Filter(func(entry observer.LoggedEntry) bool {
+		_, exists := entry.ContextMap()["repeated"]
+		return exists
+	}).All()
+}
+
+func TestDedupBursts(t *testing.T) {
+	l, logs := newDedupLogger(DedupConfig{Window: time.Minute, Idle: 20 * time.Millisecond, Fields: []string{"host"}})
+	for i := 0; i < 5; i++ {
+		l.Warn("unreachable", zap.String("host", "db-1"), zap.Int("attempt", i))
+	}
+	l.Warn("unreachable", zap.String("host", "db-2"))
+	// The selected fields added with With repeat those of the log call.
+	l.With(zap.String("host", "db-1")).Warn("unreachable")
+	l.Info("unreachable", zap.String("host", "db-1"))
+
+	// The first entry of every burst is logged immediately, with its fields.
+	if count := logs.Len(); count != 3 {
+		t.Fatalf("logged %d entries before the bursts ended, want 3", count)
+	}
+	if first := logs.All()[0].ContextMap(); first["attempt"] != int64(0) {
+		t.Errorf("unexpected first entry %v", first)
+	}
+
+	waitFor(t, func() bool { return len(summaries(logs)) > 0 })
+	time.Sleep(50 * time.Millisecond)
+	collapsed := summaries(logs)
+	if len(collapsed) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(collapsed))
+	}
+	summary := collapsed[0]
+	fields := summary.ContextMap()
+	if summary.Level != zapcore.WarnLevel || summary.Message != "unreachable" ||
+		fields["host"] != "db-1" || fields["repeated"] != int64(5) {
+		t.Errorf("unexpected summary %v %v", summary.Entry, fields)
+	}
+	if _, exists := fields["attempt"]; exists {
+		t.Error("summary has a field that is not selected")
+	}
+	if span, _ := fields["repeat_span"].(time.Duration); span <= 0 || span > time.Second {
+		t.Errorf("repeat_span = %v", fields["repeat_span"])
+	}
+}
+
+func TestDedupWindowCloses(t *testing.T) {
+	l, logs := newDedupLogger(DedupConfig{Window: 50 * time.Millisecond, Idle: 40 * time.Millisecond})
+	for start := time.Now(); time.Since(start) < 300*time.Millisecond; {
+		l.Warn("retrying")
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The repeats never idle, so only the window ends the bursts.
+	collapsed := summaries(logs)
+	if len(collapsed) < 2 {
+		t.Fatalf("got %d summaries while repeating, want the window to close the bursts", len(collapsed))
+	}
+	for _, summary := range collapsed {
+		if span, _ := summary.ContextMap()["repeat_span"].(time.Duration); span > 100*time.Millisecond {
+			t.Errorf("repeat_span %v is longer than the window", span)
+		}
+	}
+}
+
+func TestDedupSync(t *testing.T) {
+	l, logs := newDedupLogger(DedupConfig{Window: time.Hour, Idle: time.Hour})
+	for i := 0; i < 3; i++ {
+		l.Error("failed")
+	}
+	l.Info("started")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	collapsed := summaries(logs)
+	if len(collapsed) != 1 || collapsed[0].ContextMap()["repeated"] != int64(2) {
+		t.Fatalf("Sync logged the summaries %v", collapsed)
+	}
+	// The bursts are closed, so the next entry is logged again.
+	l.Error("failed")
+	if count := logs.FilterMessage("failed").Len(); count != 3 {
+		t.Errorf("logged %d failed entries, want 3", count)
+	}
+}
+
+func TestDedupIdleAtMostWindow(t *testing.T) {
+	l, _ := newDedupLogger(DedupConfig{Window: 20 * time.Millisecond, Idle: time.Minute})
+	if idle := l.Core().(*dedupCore).state.cfg.Idle; idle != 20*time.Millisecond {
+		t.Errorf("Idle = %v, want the window", idle)
+	}
+}
+
+// waitFor waits until done returns true.
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !done(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
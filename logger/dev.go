@@ -0,0 +1,365 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// DevEncoding is the name of the developer console encoding.
+const DevEncoding = "dev"
+
+// devColorEncoding is the colored developer console encoding, which New
+// selects for DevEncoding when every output is a terminal.
+const devColorEncoding = "dev+color"
+
+func init() {
+	zap.RegisterEncoder(DevEncoding, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewDevEncoder(cfg, false), nil
+	})
+	zap.RegisterEncoder(devColorEncoding, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewDevEncoder(cfg, true), nil
+	})
+}
+
+// devColorOutputs returns true if the outputs are only stderr and stdout,
+// with colors enabled for each.
+func devColorOutputs(paths []string) bool {
+	for _, path := range paths {
+		switch {
+		case path == "stderr" && ColorEnabled(os.Stderr):
+		case path == "stdout" && ColorEnabled(os.Stdout):
+		default:
+			return false
+		}
+	}
+	return len(paths) > 0
+}
+
+// Widths the columns of the developer console are padded to.
+const (
+	devCallerWidth  = 24
+	devMessageWidth = 40
+	devTraceLength  = 8
+)
+
+// ANSI escape sequences of the developer console.
+const (
+	devReset = "\x1b[0m"
+	devDim   = "\x1b[2m"
+	devBold  = "\x1b[1m"
+)
+
+// devLevelColors are the colors of the levels.
+var devLevelColors = map[zapcore.Level]string{
+	TraceLevel:          "\x1b[35m",
+	zapcore.DebugLevel:  "\x1b[36m",
+	zapcore.InfoLevel:   "\x1b[32m",
+	zapcore.WarnLevel:   "\x1b[33m",
+	zapcore.ErrorLevel:  "\x1b[31m",
+	zapcore.DPanicLevel: "\x1b[1;31m",
+	zapcore.PanicLevel:  "\x1b[1;31m",
+	zapcore.FatalLevel:  "\x1b[1;31m",
+}
+
+// devTraceKeys are the keys of the trace ID in the fields of the schemas,
+// which replace the Trace field.
+var devTraceKeys = map[string]bool{
+	"trace.id":                     true,
+	"dd.trace_id":                  true,
+	"logging.googleapis.com/trace": true,
+}
+
+// devTraceColors are the colors trace IDs are hashed to.
+var devTraceColors = []string{
+	"\x1b[34m", "\x1b[35m", "\x1b[36m", "\x1b[32m", "\x1b[33m",
+	"\x1b[94m", "\x1b[95m", "\x1b[96m", "\x1b[92m", "\x1b[93m",
+}
+
+var devPool = buffer.NewPool()
+
+// ColorEnabled returns true if the file is a terminal and colors are not
+// disabled with NO_COLOR or TERM=dumb, or if FORCE_COLOR is set.
+func ColorEnabled(file *os.File) bool {
+	if os.Getenv("FORCE_COLOR") != "" {
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// devField is a rendered field of the developer console.
+type devField struct {
+	key   string
+	value string
+}
+
+// devEncoder renders entries for reading in a terminal: a header of time,
+// level, short trace ID, logger name and caller padded to a column, the
+// message padded to another, the fields as key=value pairs separated by
+// spaces and the stacktrace on separate lines.
+type devEncoder struct {
+	cfg   zapcore.EncoderConfig
+	color bool
+
+	fields    []devField
+	namespace string
+	traceID   string
+}
+
+// NewDevEncoder creates the encoder of the "dev" encoding. New colors it
+// when its outputs are terminals. The keys of the config only select the parts of the
+// entry shown; the time, level and caller encoders are not used.
+func NewDevEncoder(cfg zapcore.EncoderConfig, color bool) zapcore.Encoder {
+	return &devEncoder{cfg: cfg, color: color}
+}
+
+func (de *devEncoder) Clone() zapcore.Encoder {
+	clone := *de
+	clone.fields = append([]devField(nil), de.fields...)
+	return &clone
+}
+
+func (de *devEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := de.Clone().(*devEncoder)
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	buf := devPool.Get()
+	if de.cfg.TimeKey != "" {
+		enc.paint(buf, devDim, entry.Time.Format("15:04:05.000"))
+		buf.AppendByte(' ')
+	}
+	if de.cfg.LevelKey != "" {
+		name := strings.ToUpper(LevelName(entry.Level))
+		enc.paint(buf, devLevelColors[entry.Level], fmt.Sprintf("%-5s", name))
+		buf.AppendByte(' ')
+	}
+	if enc.traceID != "" {
+		short := enc.traceID
+		if len(short) > devTraceLength {
+			short = short[:devTraceLength]
+		}
+		enc.paint(buf, devTraceColor(enc.traceID), short)
+		buf.AppendByte(' ')
+	}
+	if de.cfg.NameKey != "" && entry.LoggerName != "" {
+		enc.paint(buf, devBold, entry.LoggerName)
+		buf.AppendByte(' ')
+	}
+	if de.cfg.CallerKey != "" && entry.Caller.Defined {
+		caller := entry.Caller.TrimmedPath()
+		enc.paint(buf, devDim, caller)
+		buf.AppendString(strings.Repeat(" ", devPadding(caller, devCallerWidth)))
+	}
+
+	buf.AppendString(entry.Message)
+	if len(enc.fields) > 0 {
+		buf.AppendString(strings.Repeat(" ", devPadding(entry.Message, devMessageWidth)))
+		for i, field := range enc.fields {
+			if i > 0 {
+				buf.AppendByte(' ')
+			}
+			enc.paint(buf, devDim, field.key+"=")
+			buf.AppendString(field.value)
+		}
+	}
+	buf.AppendByte('\n')
+
+	if entry.Stack != "" && de.cfg.StacktraceKey != "" {
+		for _, line := range strings.Split(entry.Stack, "\n") {
+			buf.AppendString("    ")
+			enc.paint(buf, devDim, line)
+			buf.AppendByte('\n')
+		}
+	}
+	return buf, nil
+}
+
+// paint appends the text in the color, if colors are enabled.
+func (de *devEncoder) paint(buf *buffer.Buffer, color, text string) {
+	if !de.color || color == "" {
+		buf.AppendString(text)
+		return
+	}
+	buf.AppendString(color)
+	buf.AppendString(text)
+	buf.AppendString(devReset)
+}
+
+// devPadding returns the spaces needed to pad the text to the width, at least one.
+func devPadding(text string, width int) int {
+	if n := width - utf8.RuneCountInString(text); n > 1 {
+		return n
+	}
+	return 1
+}
+
+// devTraceColor returns the color of a trace ID, the same for every entry of the trace.
+func devTraceColor(traceID string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(traceID))
+	return devTraceColors[hash.Sum32()%uint32(len(devTraceColors))]
+}
+
+// add appends a rendered field.
+func (de *devEncoder) add(key, value string) {
+	de.fields = append(de.fields, devField{key: de.namespace + key, value: value})
+}
+
+// addText appends a string field, quoted if it would be ambiguous unquoted.
+func (de *devEncoder) addText(key, value string) {
+	if value == "" || strings.ContainsAny(value, " =\"\t\n\r") || !utf8.ValidString(value) {
+		value = strconv.Quote(value)
+	}
+	de.add(key, value)
+}
+
+// addJSON appends a field rendered as compact JSON.
+func (de *devEncoder) addJSON(key string, value interface{}) error {
+	data, err := json.Marshal(normalize(value))
+	if err != nil {
+		return err
+	}
+	de.add(key, string(data))
+	return nil
+}
+
+func (de *devEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := enc.AddArray(key, marshaler); err != nil {
+		return err
+	}
+	return de.addJSON(key, enc.Fields[key])
+}
+
+func (de *devEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := marshaler.MarshalLogObject(enc); err != nil {
+		return err
+	}
+	// The trace is shown as a short colored ID in the header.
+	if key == TraceFieldKey && de.namespace == "" {
+		if traceID, ok := enc.Fields["trace_id"].(string); ok {
+			de.traceID = traceID
+			return nil
+		}
+	}
+	return de.addJSON(key, enc.Fields)
+}
+
+func (de *devEncoder) AddBinary(key string, value []byte) {
+	de.add(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (de *devEncoder) AddByteString(key string, value []byte) {
+	de.addText(key, string(value))
+}
+
+func (de *devEncoder) AddBool(key string, value bool) {
+	de.add(key, strconv.FormatBool(value))
+}
+
+func (de *devEncoder) AddComplex128(key string, value complex128) {
+	de.add(key, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (de *devEncoder) AddComplex64(key string, value complex64) {
+	de.add(key, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (de *devEncoder) AddDuration(key string, value time.Duration) {
+	de.add(key, value.String())
+}
+
+func (de *devEncoder) AddFloat64(key string, value float64) {
+	de.add(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (de *devEncoder) AddFloat32(key string, value float32) {
+	de.add(key, strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+func (de *devEncoder) AddInt(key string, value int) {
+	de.add(key, strconv.FormatInt(int64(value), 10))
+}
+
+func (de *devEncoder) AddInt64(key string, value int64) {
+	de.add(key, strconv.FormatInt(value, 10))
+}
+
+func (de *devEncoder) AddInt32(key string, value int32) {
+	de.add(key, strconv.FormatInt(int64(value), 10))
+}
+
+func (de *devEncoder) AddInt16(key string, value int16) {
+	de.add(key, strconv.FormatInt(int64(value), 10))
+}
+
+func (de *devEncoder) AddInt8(key string, value int8) {
+	de.add(key, strconv.FormatInt(int64(value), 10))
+}
+
+func (de *devEncoder) AddString(key, value string) {
+	// The trace of a schema is shown in the header too, without the
+	// resource name of Google Cloud.
+	if de.namespace == "" && devTraceKeys[key] && value != "" {
+		de.traceID = value[strings.LastIndexByte(value, '/')+1:]
+		return
+	}
+	de.addText(key, value)
+}
+
+func (de *devEncoder) AddTime(key string, value time.Time) {
+	de.add(key, value.Format("2006-01-02T15:04:05.000Z07:00"))
+}
+
+func (de *devEncoder) AddUint(key string, value uint) {
+	de.add(key, strconv.FormatUint(uint64(value), 10))
+}
+
+func (de *devEncoder) AddUint64(key string, value uint64) {
+	de.add(key, strconv.FormatUint(value, 10))
+}
+
+func (de *devEncoder) AddUint32(key string, value uint32) {
+	de.add(key, strconv.FormatUint(uint64(value), 10))
+}
+
+func (de *devEncoder) AddUint16(key string, value uint16) {
+	de.add(key, strconv.FormatUint(uint64(value), 10))
+}
+
+func (de *devEncoder) AddUint8(key string, value uint8) {
+	de.add(key, strconv.FormatUint(uint64(value), 10))
+}
+
+func (de *devEncoder) AddUintptr(key string, value uintptr) {
+	de.add(key, "0x"+strconv.FormatUint(uint64(value), 16))
+}
+
+func (de *devEncoder) AddReflected(key string, value interface{}) error {
+	if text, ok := value.(string); ok {
+		de.addText(key, text)
+		return nil
+	}
+	return de.addJSON(key, value)
+}
+
+func (de *devEncoder) OpenNamespace(key string) {
+	de.namespace += key + "."
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// devLine encodes an entry with the message and fields with the dev encoder.
+func devLine(t *testing.T, fields ...zapcore.Field) string {
+	t.Helper()
+	cfg := DefaultConfig.EncoderConfig
+	enc := NewDevEncoder(cfg, false)
+	entry := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2024, 1, 2, 14, 2, 11, 384e6, time.UTC),
+		Message: "order created",
+		Caller:  zapcore.NewEntryCaller(0, "/src/api/handler.go", 42, true),
+	}
+	buf, err := enc.EncodeEntry(entry, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry: %v", err)
+	}
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestDevEncoderLine(t *testing.T) {
+	line := devLine(t, zap.Int("order_id", 42), zap.String("note", "gift wrap"))
+	want := "14:02:11.384 INFO  api/handler.go:42" + strings.Repeat(" ", 7) + "order created" + strings.Repeat(" ", 27) +
+		`order_id=42 note="gift wrap"` + "\n"
+	if line != want {
+		t.Errorf("line = %q, want %q", line, want)
+	}
+}
+
+func TestDevEncoderTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	trace := zap.Object(TraceFieldKey, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("trace_id", traceID)
+		enc.AddString("span_id", "00f067aa0ba902b7")
+		return nil
+	}))
+	tests := []struct {
+		name   string
+		fields []zapcore.Field
+		prefix string
+	}{
+		{"Trace field", []zapcore.Field{trace}, "4bf92f35"},
+		{"ECS", SchemaECS.Trace(traceID, "00f067aa0ba902b7"), "4bf92f35"},
+		{"Google Cloud", SchemaGoogleCloud("my-project").Trace(traceID, "00f067aa0ba902b7"), "4bf92f35"},
+		{"Datadog", SchemaDatadog.Trace(traceID, "00f067aa0ba902b7"), hexToDecimal(traceID)[:devTraceLength]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := devLine(t, tt.fields...)
+			if !strings.HasPrefix(line, "14:02:11.384 INFO  "+tt.prefix+" api/handler.go:42") {
+				t.Errorf("line %q has no trace prefix %q", line, tt.prefix)
+			}
+			if strings.Contains(line, traceID) {
+				t.Errorf("line %q repeats the trace ID in the fields", line)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorField logs an error under the "error" key. See NamedErrorField.
+func ErrorField(err error) zapcore.Field {
+	return NamedErrorField("error", err)
+}
+
+// NamedErrorField logs the message of an error under the key, the errors it
+// wraps as an array under key_chain and the stack where it was created, if
+// it carries one, under key_stack. Stacks are read from errors with a
+// Callers() []uintptr method, like the coded errors of this module, or a
+// StackTrace method returning program counters, like github.com/pkg/errors.
+//
+// Loggers built with New log the errors of zap.Error fields the same way.
+func NamedErrorField(key string, err error) zapcore.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Inline(errorFields{key: key, err: err, stack: true})
+}
+
+// WithErrorStacks adds the stacks of errors to the entries at the enabled
+// levels only. It defaults to ErrorLevel, and nil never adds them.
+func WithErrorStacks(level zapcore.LevelEnabler) Option {
+	return func(o *options) {
+		o.errorStacks = level
+	}
+}
+
+// errorFields encodes an error as its message, chain and stack.
+type errorFields struct {
+	key   string
+	err   error
+	stack bool
+}
+
+func (ef errorFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString(ef.key, ef.err.Error())
+	if chain := chainOf(ef.err); len(chain) > 1 || isJoined(ef.err) {
+		enc.AddArray(ef.key+"_chain", chain)
+	}
+	if ef.stack {
+		if stack := ErrorStack(ef.err); stack != "" {
+			enc.AddString(ef.key+"_stack", stack)
+		}
+	}
+	return nil
+}
+
+// errorChain is an error followed by the errors it wraps.
+type errorChain []error
+
+// chainOf follows the errors wrapped by err. Joined errors end the chain.
+func chainOf(err error) errorChain {
+	var chain errorChain
+	for err != nil {
+		chain = append(chain, err)
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// isJoined returns true if the error wraps several errors.
+func isJoined(err error) bool {
+	_, ok := err.(interface{ Unwrap() []error })
+	return ok
+}
+
+func (ec errorChain) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range ec {
+		if err := enc.AppendObject(errorLink{err: err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorLink encodes an error of a chain. Joined errors add the chain of
+// every error they join.
+type errorLink struct {
+	err error
+}
+
+func (el errorLink) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", el.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", el.err))
+	if joined, ok := el.err.(interface{ Unwrap() []error }); ok {
+		return enc.AddArray("errors", errorChains(joined.Unwrap()))
+	}
+	return nil
+}
+
+// errorChains encodes the chains of joined errors.
+type errorChains []error
+
+func (ecs errorChains) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range ecs {
+		if err == nil {
+			continue
+		}
+		if err := enc.AppendArray(chainOf(err)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrorStack formats the stack carried by the error, or by the innermost
+// error of its chain carrying one, like the stacktraces of zap.
+func ErrorStack(err error) string {
+	var pcs []uintptr
+	for ; err != nil; err = errors.Unwrap(err) {
+		if callers := callersOf(err); len(callers) > 0 {
+			pcs = callers
+		}
+	}
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.goexit" {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// callersOf returns the program counters of the stack of an error.
+func callersOf(err error) []uintptr {
+	if stack, ok := err.(interface{ Callers() []uintptr }); ok {
+		return stack.Callers()
+	}
+
+	// The StackTrace of github.com/pkg/errors returns a slice of frames,
+	// which are program counters, without depending on the package.
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+	kind := method.Type()
+	if kind.NumIn() != 0 || kind.NumOut() != 1 ||
+		kind.Out(0).Kind() != reflect.Slice || kind.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	frames := method.Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
+
+// errorCore logs the errors of zap.Error fields with their chain, and adds
+// the stacks of errors at the enabled levels only. With readable stacks the
+// error stacks are appended to the stacktrace of the entry instead, which the
+// console encoder prints on separate lines.
+type errorCore struct {
+	zapcore.Core
+	stacks   zapcore.LevelEnabler
+	readable bool
+}
+
+func (ec *errorCore) With(fields []zapcore.Field) zapcore.Core {
+	// The level is not known yet, so the errors of the context have no stacks.
+	mapped, _ := ec.mapFields(fields, false)
+	return &errorCore{Core: ec.Core.With(mapped), stacks: ec.stacks, readable: ec.readable}
+}
+
+func (ec *errorCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checkRewrite(ec, ec.Core, entry, checked)
+}
+
+func (ec *errorCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return ec.Core.Write(ec.rewrite(entry, fields))
+}
+
+// rewrite encodes the errors of the fields, and appends their stacks to the
+// stack of the entry when they are printed below it.
+func (ec *errorCore) rewrite(entry zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	stacks := ec.stacks != nil && ec.stacks.Enabled(entry.Level)
+	mapped, errs := ec.mapFields(fields, stacks && !ec.readable)
+	if stacks && ec.readable {
+		for _, ef := range errs {
+			if stack := ErrorStack(ef.err); stack != "" {
+				if entry.Stack != "" {
+					entry.Stack += "\n"
+				}
+				entry.Stack += ef.key + ": " + ef.err.Error() + "\n" + stack
+			}
+		}
+	}
+	return entry, mapped
+}
+
+// mapFields returns the fields with the errors encoded as errorFields,
+// with or without their stack, and the errors found. The slice is only
+// copied when it contains an error.
+func (ec *errorCore) mapFields(fields []zapcore.Field, stack bool) ([]zapcore.Field, []errorFields) {
+	var mapped []zapcore.Field
+	var errs []errorFields
+	for i, field := range fields {
+		var ef errorFields
+		switch field.Type {
+		case zapcore.ErrorType:
+			err, ok := field.Interface.(error)
+			if !ok || err == nil {
+				continue
+			}
+			ef = errorFields{key: field.Key, err: err}
+		case zapcore.InlineMarshalerType:
+			var ok bool
+			if ef, ok = field.Interface.(errorFields); !ok {
+				continue
+			}
+		default:
+			continue
+		}
+
+		errs = append(errs, ef)
+		ef.stack = stack
+		if mapped == nil {
+			mapped = append(make([]zapcore.Field, 0, len(fields)), fields...)
+		}
+		mapped[i] = zap.Inline(ef)
+	}
+	if mapped == nil {
+		return fields, nil
+	}
+	return mapped, errs
+}
@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// callersError carries a stack like the coded errors of this module.
+type callersError struct {
+	pcs []uintptr
+}
+
+func newCallersError() *callersError {
+	pcs := make([]uintptr, 32)
+	return &callersError{pcs: pcs[:runtime.Callers(2, pcs)]}
+}
+
+func (ce *callersError) Error() string      { return "callers" }
+func (ce *callersError) Callers() []uintptr { return ce.pcs }
+
+// stackFrame and stackTrace mirror the types of github.com/pkg/errors.
+type stackFrame uintptr
+type stackTrace []stackFrame
+
+// pkgError carries a stack like the errors of github.com/pkg/errors.
+type pkgError struct {
+	stack stackTrace
+}
+
+func newPkgError() *pkgError {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	stack := make(stackTrace, len(pcs))
+	for i, pc := range pcs {
+		stack[i] = stackFrame(pc)
+	}
+	return &pkgError{stack: stack}
+}
+
+func (pe *pkgError) Error() string          { return "pkg" }
+func (pe *pkgError) StackTrace() stackTrace { return pe.stack }
+
+// encodeField encodes a field into a map.
+func encodeField(field zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return enc.Fields
+}
+
+func TestErrorFieldChain(t *testing.T) {
+	base := errors.New("connection refused")
+	wrapped := fmt.Errorf("query orders: %w", base)
+
+	fields := encodeField(ErrorField(wrapped))
+	if fields["error"] != "query orders: connection refused" {
+		t.Errorf("error = %v", fields["error"])
+	}
+	chain, _ := fields["error_chain"].([]interface{})
+	if len(chain) != 2 {
+		t.Fatalf("error_chain = %v", fields["error_chain"])
+	}
+	if link := chain[1].(map[string]interface{}); link["message"] != "connection refused" || link["type"] != "*errors.errorString" {
+		t.Errorf("unexpected link %v", link)
+	}
+	if _, exists := fields["error_stack"]; exists {
+		t.Error("error without a stack logged with one")
+	}
+	if fields := encodeField(ErrorField(base)); fields["error_chain"] != nil {
+		t.Errorf("error without a chain logged with one: %v", fields)
+	}
+
+	joined := errors.Join(base, wrapped)
+	fields = encodeField(NamedErrorField("cause", joined))
+	chain, _ = fields["cause_chain"].([]interface{})
+	if len(chain) != 1 {
+		t.Fatalf("cause_chain = %v", fields["cause_chain"])
+	}
+	chains, _ := chain[0].(map[string]interface{})["errors"].([]interface{})
+	if len(chains) != 2 || len(chains[0].([]interface{})) != 1 || len(chains[1].([]interface{})) != 2 {
+		t.Errorf("joined errors = %v, want the chain of each error", chains)
+	}
+	if field := ErrorField(nil); field.Type != zapcore.SkipType {
+		t.Errorf("nil error logged as %v", field)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	const caller = "github.com/jimxshaw/tracerlogger/logger.TestErrorStack"
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"Callers", newCallersError()},
+		{"StackTrace", newPkgError()},
+		{"wrapped", fmt.Errorf("failed: %w", newCallersError())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := ErrorStack(tt.err)
+			if !strings.HasPrefix(stack, caller+"\n\t") || !strings.Contains(stack, "errors_test.go:") {
+				t.Errorf("stack does not start at the caller:\n%s", stack)
+			}
+			if strings.Contains(stack, "runtime.goexit") {
+				t.Errorf("stack has runtime.goexit:\n%s", stack)
+			}
+			if fields := encodeField(ErrorField(tt.err)); fields["error_stack"] != stack {
+				t.Errorf("error_stack = %v", fields["error_stack"])
+			}
+		})
+	}
+
+	// The innermost stack of the chain is the closest to the cause.
+	inner := func() error { return newCallersError() }()
+	outer := &wrappedCallersError{callersError: newCallersError(), err: inner}
+	if stack := ErrorStack(outer); !strings.HasPrefix(stack, caller+".func") {
+		t.Errorf("stack is not the innermost one:\n%s", stack)
+	}
+	if stack := ErrorStack(errors.New("plain")); stack != "" {
+		t.Errorf("plain error has the stack %q", stack)
+	}
+}
+
+// wrappedCallersError carries a stack and wraps another error.
+type wrappedCallersError struct {
+	*callersError
+	err error
+}
+
+func (we *wrappedCallersError) Unwrap() error { return we.err }
+
+// newErrorLogger builds a Logger writing to a file with the encoding, and
+// returns a function reading the lines written.
+func newErrorLogger(t *testing.T, encoding string, opts ...Option) (*Logger, func() []string) {
+	t.Helper()
+	cfg := DefaultConfig
+	cfg.Encoding = encoding
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	cfg.EncoderConfig.StacktraceKey = "stacktrace"
+	cfg.DisableStacktrace = true
+	path := filepath.Join(t.TempDir(), "log")
+	cfg.OutputPaths = []string{path}
+	l, err := New(cfg, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return l, func() []string {
+		l.Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+}
+
+func TestWithErrorStacks(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		stacks map[zapcore.Level]bool
+	}{
+		{"default", nil, map[zapcore.Level]bool{zapcore.WarnLevel: false, zapcore.ErrorLevel: true}},
+		{"warn", []Option{WithErrorStacks(zapcore.WarnLevel)}, map[zapcore.Level]bool{zapcore.InfoLevel: false, zapcore.WarnLevel: true}},
+		{"never", []Option{WithErrorStacks(nil)}, map[zapcore.Level]bool{zapcore.ErrorLevel: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, lines := newErrorLogger(t, "json", tt.opts...)
+			var levels []zapcore.Level
+			for level := range tt.stacks {
+				levels = append(levels, level)
+			}
+			for _, level := range levels {
+				l.zap.Log(level, "failed", zap.Error(fmt.Errorf("wrapped: %w", newCallersError())))
+			}
+			// The errors of With have no stacks, as the level is not known yet.
+			l.With(zap.Error(newCallersError())).Error("context")
+
+			logged := lines()
+			if len(logged) != len(levels)+1 {
+				t.Fatalf("got %d lines", len(logged))
+			}
+			for i, level := range levels {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(logged[i]), &entry); err != nil {
+					t.Fatalf("invalid line %q: %v", logged[i], err)
+				}
+				if _, exists := entry["error_chain"]; !exists {
+					t.Errorf("%v entry has no chain: %s", level, logged[i])
+				}
+				if _, exists := entry["error_stack"]; exists != tt.stacks[level] {
+					t.Errorf("%v entry has a stack: %v, want %v", level, exists, tt.stacks[level])
+				}
+			}
+			if strings.Contains(logged[len(levels)], "error_stack") {
+				t.Errorf("With error logged with a stack: %s", logged[len(levels)])
+			}
+		})
+	}
+}
+
+func TestErrorStacksReadable(t *testing.T) {
+	l, lines := newErrorLogger(t, "console")
+	l.Error("failed", zap.Error(fmt.Errorf("wrapped: %w", newCallersError())))
+	l.Warn("retrying", zap.Error(newCallersError()))
+
+	logged := lines()
+	if strings.Contains(logged[0], "error_stack") {
+		t.Errorf("readable stack logged as a field: %s", logged[0])
+	}
+	if len(logged) < 3 || logged[1] != "error: wrapped: callers" ||
+		logged[2] != "github.com/jimxshaw/tracerlogger/logger.TestErrorStacksReadable" {
+		t.Fatalf("the stack is not printed below the entry:\n%s", strings.Join(logged, "\n"))
+	}
+	last := logged[len(logged)-1]
+	if !strings.Contains(last, "retrying") || strings.Contains(last, "error_stack") {
+		t.Errorf("Warn entry logged with a stack: %s", last)
+	}
+}
@@ -0,0 +1,355 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// freezeFields returns the fields with the values that may change after the
+// log call returns encoded now: marshalers, reflected values, Stringers and
+// byte slices. The keys and types of the fields are kept, so that the cores
+// looking at them still recognize them. Errors are kept as they are.
+func freezeFields(fields []zapcore.Field) []zapcore.Field {
+	frozen := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		frozen[i] = freezeField(field)
+	}
+	return frozen
+}
+
+// freezeField encodes the value of a field that may change.
+func freezeField(field zapcore.Field) zapcore.Field {
+	switch field.Type {
+	case zapcore.ObjectMarshalerType:
+		return zap.Object(field.Key, freezeObject(field.Interface.(zapcore.ObjectMarshaler)))
+	case zapcore.ArrayMarshalerType:
+		return zap.Array(field.Key, freezeArray(field.Interface.(zapcore.ArrayMarshaler)))
+	case zapcore.InlineMarshalerType:
+		// The errorCore recognizes its own fields, which only hold an error.
+		if _, ok := field.Interface.(errorFields); ok {
+			return field
+		}
+		return zap.Inline(freezeObject(field.Interface.(zapcore.ObjectMarshaler)))
+	case zapcore.ReflectType:
+		if raw, err := freezeReflected(field.Interface); err == nil {
+			return zap.Reflect(field.Key, raw)
+		}
+	case zapcore.StringerType:
+		return freezeStringer(field)
+	case zapcore.BinaryType, zapcore.ByteStringType:
+		field.Interface = append([]byte(nil), field.Interface.([]byte)...)
+	}
+	return field
+}
+
+// freezeReflected encodes a value like the reflected encoder of zap.
+func freezeReflected(value interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// freezeStringer calls String now. A panicking Stringer is left to the
+// encoder, which reports it like for a synchronous entry.
+func freezeStringer(field zapcore.Field) (frozen zapcore.Field) {
+	defer func() {
+		if recover() != nil {
+			frozen = field
+		}
+	}()
+	return zap.String(field.Key, field.Interface.(fmt.Stringer).String())
+}
+
+// frozenObject replays the encoding of an object recorded earlier.
+type frozenObject struct {
+	calls []func(enc zapcore.ObjectEncoder) error
+	err   error
+}
+
+// freezeObject records the encoding of the object.
+func freezeObject(marshaler zapcore.ObjectMarshaler) *frozenObject {
+	fo := &frozenObject{}
+	fo.err = marshaler.MarshalLogObject(fo)
+	return fo
+}
+
+func (fo *frozenObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, call := range fo.calls {
+		if err := call(enc); err != nil {
+			return err
+		}
+	}
+	return fo.err
+}
+
+// add records a call of the encoder.
+func (fo *frozenObject) add(call func(enc zapcore.ObjectEncoder)) {
+	fo.calls = append(fo.calls, func(enc zapcore.ObjectEncoder) error {
+		call(enc)
+		return nil
+	})
+}
+
+func (fo *frozenObject) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	frozen := freezeArray(marshaler)
+	fo.calls = append(fo.calls, func(enc zapcore.ObjectEncoder) error {
+		return enc.AddArray(key, frozen)
+	})
+	return nil
+}
+
+func (fo *frozenObject) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	frozen := freezeObject(marshaler)
+	fo.calls = append(fo.calls, func(enc zapcore.ObjectEncoder) error {
+		return enc.AddObject(key, frozen)
+	})
+	return nil
+}
+
+func (fo *frozenObject) AddReflected(key string, value interface{}) error {
+	raw, err := freezeReflected(value)
+	if err != nil {
+		return err
+	}
+	fo.calls = append(fo.calls, func(enc zapcore.ObjectEncoder) error {
+		return enc.AddReflected(key, raw)
+	})
+	return nil
+}
+
+func (fo *frozenObject) AddBinary(key string, value []byte) {
+	value = append([]byte(nil), value...)
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddBinary(key, value) })
+}
+
+func (fo *frozenObject) AddByteString(key string, value []byte) {
+	value = append([]byte(nil), value...)
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddByteString(key, value) })
+}
+
+func (fo *frozenObject) AddBool(key string, value bool) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddBool(key, value) })
+}
+
+func (fo *frozenObject) AddComplex128(key string, value complex128) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddComplex128(key, value) })
+}
+
+func (fo *frozenObject) AddComplex64(key string, value complex64) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddComplex64(key, value) })
+}
+
+func (fo *frozenObject) AddDuration(key string, value time.Duration) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddDuration(key, value) })
+}
+
+func (fo *frozenObject) AddFloat64(key string, value float64) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddFloat64(key, value) })
+}
+
+func (fo *frozenObject) AddFloat32(key string, value float32) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddFloat32(key, value) })
+}
+
+func (fo *frozenObject) AddInt(key string, value int) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddInt(key, value) })
+}
+
+func (fo *frozenObject) AddInt64(key string, value int64) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddInt64(key, value) })
+}
+
+func (fo *frozenObject) AddInt32(key string, value int32) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddInt32(key, value) })
+}
+
+func (fo *frozenObject) AddInt16(key string, value int16) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddInt16(key, value) })
+}
+
+func (fo *frozenObject) AddInt8(key string, value int8) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddInt8(key, value) })
+}
+
+func (fo *frozenObject) AddString(key, value string) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddString(key, value) })
+}
+
+func (fo *frozenObject) AddTime(key string, value time.Time) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddTime(key, value) })
+}
+
+func (fo *frozenObject) AddUint(key string, value uint) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUint(key, value) })
+}
+
+func (fo *frozenObject) AddUint64(key string, value uint64) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUint64(key, value) })
+}
+
+func (fo *frozenObject) AddUint32(key string, value uint32) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUint32(key, value) })
+}
+
+func (fo *frozenObject) AddUint16(key string, value uint16) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUint16(key, value) })
+}
+
+func (fo *frozenObject) AddUint8(key string, value uint8) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUint8(key, value) })
+}
+
+func (fo *frozenObject) AddUintptr(key string, value uintptr) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.AddUintptr(key, value) })
+}
+
+func (fo *frozenObject) OpenNamespace(key string) {
+	fo.add(func(enc zapcore.ObjectEncoder) { enc.OpenNamespace(key) })
+}
+
+// frozenArray replays the encoding of an array recorded earlier.
+type frozenArray struct {
+	calls []func(enc zapcore.ArrayEncoder) error
+	err   error
+}
+
+// freezeArray records the encoding of the array.
+func freezeArray(marshaler zapcore.ArrayMarshaler) *frozenArray {
+	fa := &frozenArray{}
+	fa.err = marshaler.MarshalLogArray(fa)
+	return fa
+}
+
+func (fa *frozenArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, call := range fa.calls {
+		if err := call(enc); err != nil {
+			return err
+		}
+	}
+	return fa.err
+}
+
+// add records a call of the encoder.
+func (fa *frozenArray) add(call func(enc zapcore.ArrayEncoder)) {
+	fa.calls = append(fa.calls, func(enc zapcore.ArrayEncoder) error {
+		call(enc)
+		return nil
+	})
+}
+
+func (fa *frozenArray) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	frozen := freezeArray(marshaler)
+	fa.calls = append(fa.calls, func(enc zapcore.ArrayEncoder) error {
+		return enc.AppendArray(frozen)
+	})
+	return nil
+}
+
+func (fa *frozenArray) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	frozen := freezeObject(marshaler)
+	fa.calls = append(fa.calls, func(enc zapcore.ArrayEncoder) error {
+		return enc.AppendObject(frozen)
+	})
+	return nil
+}
+
+func (fa *frozenArray) AppendReflected(value interface{}) error {
+	raw, err := freezeReflected(value)
+	if err != nil {
+		return err
+	}
+	fa.calls = append(fa.calls, func(enc zapcore.ArrayEncoder) error {
+		return enc.AppendReflected(raw)
+	})
+	return nil
+}
+
+func (fa *frozenArray) AppendBool(value bool) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendBool(value) })
+}
+
+func (fa *frozenArray) AppendByteString(value []byte) {
+	value = append([]byte(nil), value...)
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendByteString(value) })
+}
+
+func (fa *frozenArray) AppendComplex128(value complex128) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendComplex128(value) })
+}
+
+func (fa *frozenArray) AppendComplex64(value complex64) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendComplex64(value) })
+}
+
+func (fa *frozenArray) AppendFloat64(value float64) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendFloat64(value) })
+}
+
+func (fa *frozenArray) AppendFloat32(value float32) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendFloat32(value) })
+}
+
+func (fa *frozenArray) AppendInt(value int) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendInt(value) })
+}
+
+func (fa *frozenArray) AppendInt64(value int64) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendInt64(value) })
+}
+
+func (fa *frozenArray) AppendInt32(value int32) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendInt32(value) })
+}
+
+func (fa *frozenArray) AppendInt16(value int16) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendInt16(value) })
+}
+
+func (fa *frozenArray) AppendInt8(value int8) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendInt8(value) })
+}
+
+func (fa *frozenArray) AppendString(value string) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendString(value) })
+}
+
+func (fa *frozenArray) AppendUint(value uint) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUint(value) })
+}
+
+func (fa *frozenArray) AppendUint64(value uint64) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUint64(value) })
+}
+
+func (fa *frozenArray) AppendUint32(value uint32) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUint32(value) })
+}
+
+func (fa *frozenArray) AppendUint16(value uint16) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUint16(value) })
+}
+
+func (fa *frozenArray) AppendUint8(value uint8) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUint8(value) })
+}
+
+func (fa *frozenArray) AppendUintptr(value uintptr) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendUintptr(value) })
+}
+
+func (fa *frozenArray) AppendDuration(value time.Duration) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendDuration(value) })
+}
+
+func (fa *frozenArray) AppendTime(value time.Time) {
+	fa.add(func(enc zapcore.ArrayEncoder) { enc.AppendTime(value) })
+}
@@ -0,0 +1,269 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// JournaldConfig configures a Journald.
+type JournaldConfig struct {
+	// Socket is the native socket of journald. It defaults to /run/systemd/journal/socket.
+	Socket string
+	// Identifier is the SYSLOG_IDENTIFIER of the entries. It defaults to the
+	// name of the executable.
+	Identifier string
+	// Facility, if set, is the SYSLOG_FACILITY of the entries.
+	Facility SyslogFacility
+	// Level selects the entries sent. It defaults to the level of the Logger.
+	Level zapcore.LevelEnabler
+}
+
+// Journald sends the log entries of a Logger to systemd-journald with its
+// native protocol. The fields of the entries become journal fields with upper
+// case names, next to MESSAGE, PRIORITY, CODE_FILE, CODE_LINE, CODE_FUNC,
+// LOGGER, STACK_TRACE and the TRACE_ID and SPAN_ID of the trace. Fields whose
+// names are taken, e.g. a "message" field, are prefixed with FIELD_.
+// Attach it with WithJournald.
+type Journald struct {
+	cfg  JournaldConfig
+	addr *net.UnixAddr
+
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+// NewJournald creates a Journald sending to the journald socket.
+func NewJournald(cfg JournaldConfig) (*Journald, error) {
+	if cfg.Socket == "" {
+		cfg.Socket = "/run/systemd/journal/socket"
+	}
+	if cfg.Identifier == "" {
+		cfg.Identifier = filepath.Base(os.Args[0])
+	}
+	if _, err := os.Stat(cfg.Socket); err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	// The socket is autobound rather than connected, since descriptors can
+	// only be passed with WriteMsgUnix to an address.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	return &Journald{cfg: cfg, addr: &net.UnixAddr{Name: cfg.Socket, Net: "unixgram"}, conn: conn}, nil
+}
+
+// WithJournald sends the entries of the Logger to the Journald.
+func WithJournald(j *Journald) Option {
+	return func(o *options) {
+		o.tees = append(o.tees, func(_ zap.Config, level zapcore.LevelEnabler) zapcore.Core {
+			if j.cfg.Level != nil {
+				level = j.cfg.Level
+			}
+			return &journaldCore{LevelEnabler: level, journald: j}
+		})
+	}
+}
+
+// Close closes the socket. Later entries are dropped.
+func (j *Journald) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	return j.conn.Close()
+}
+
+// send writes an entry, passing it as a file if it is too large for a datagram.
+func (j *Journald) send(payload []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+
+	_, err := j.conn.WriteToUnix(payload, j.addr)
+	if err != nil && isMessageTooLarge(err) {
+		err = sendJournalFile(j.conn, j.addr, payload)
+	}
+	if err != nil {
+		return fmt.Errorf("journald: %w", err)
+	}
+	return nil
+}
+
+// journalEntry builds the payload of an entry in the native protocol.
+type journalEntry struct {
+	bytes.Buffer
+	names map[string]bool
+}
+
+// add adds a field, once per name. Values with newlines are length prefixed.
+func (je *journalEntry) add(name, value string) {
+	if name == "" || je.names[name] {
+		return
+	}
+	je.names[name] = true
+	je.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		je.WriteByte('=')
+		je.WriteString(value)
+		je.WriteByte('\n')
+		return
+	}
+	je.WriteByte('\n')
+	binary.Write(&je.Buffer, binary.LittleEndian, uint64(len(value)))
+	je.WriteString(value)
+	je.WriteByte('\n')
+}
+
+// addField adds a field of the entry. A name already taken is prefixed with
+// FIELD_, and numbered if that is taken too.
+func (je *journalEntry) addField(key, value string) {
+	name := journalFieldName(key)
+	if name == "" {
+		return
+	}
+	base := name
+	for n := 1; je.names[name]; n++ {
+		prefixed, suffix := "FIELD_"+base, ""
+		if n > 1 {
+			suffix = "_" + strconv.Itoa(n)
+		}
+		if len(prefixed)+len(suffix) > 64 {
+			prefixed = prefixed[:64-len(suffix)]
+		}
+		name = prefixed + suffix
+	}
+	je.add(name, value)
+}
+
+// journalFieldName converts a key to a journal field name: upper case
+// letters, digits and underscores, not starting with an underscore or a
+// digit, of at most 64 characters.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// journalValue formats a normalized field value.
+func journalValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case time.Duration:
+		return value.String()
+	case nil:
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// journaldCore writes the entries of a Logger to a Journald.
+type journaldCore struct {
+	zapcore.LevelEnabler
+	journald *Journald
+	fields   []zapcore.Field
+}
+
+func (jc *journaldCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(jc.fields)+len(fields))
+	combined = append(combined, jc.fields...)
+	return &journaldCore{LevelEnabler: jc.LevelEnabler, journald: jc.journald, fields: append(combined, fields...)}
+}
+
+func (jc *journaldCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if jc.Enabled(entry.Level) {
+		return checked.AddCore(entry, jc)
+	}
+	return checked
+}
+
+func (jc *journaldCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range jc.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	values := normalize(enc.Fields).(map[string]interface{})
+
+	cfg := jc.journald.cfg
+	je := &journalEntry{names: map[string]bool{}}
+	je.add("MESSAGE", entry.Message)
+	je.add("PRIORITY", strconv.Itoa(SyslogSeverity(entry.Level)))
+	je.add("SYSLOG_IDENTIFIER", cfg.Identifier)
+	if cfg.Facility != 0 {
+		je.add("SYSLOG_FACILITY", strconv.Itoa(int(cfg.Facility)))
+	}
+	if entry.Caller.Defined {
+		je.add("CODE_FILE", entry.Caller.File)
+		je.add("CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		je.add("CODE_FUNC", entry.Caller.Function)
+	}
+	if entry.LoggerName != "" {
+		je.add("LOGGER", entry.LoggerName)
+	}
+	if entry.Stack != "" {
+		je.add("STACK_TRACE", entry.Stack)
+	}
+	if traceID := traceIDOf(values); traceID != "" {
+		je.add("TRACE_ID", traceID)
+	}
+	if trace, ok := values[TraceFieldKey].(map[string]interface{}); ok {
+		if spanID, ok := trace["span_id"].(string); ok && spanID != "" {
+			je.add("SPAN_ID", spanID)
+		}
+		delete(values, TraceFieldKey)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		je.addField(key, journalValue(values[key]))
+	}
+	return jc.journald.send(je.Bytes())
+}
+
+func (jc *journaldCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package logger
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// isMessageTooLarge returns true if a datagram was rejected for its size.
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile passes a large entry to journald as the descriptor of an
+// unlinked temporary file, as sd_journal_send does.
+func sendJournalFile(conn *net.UnixConn, addr *net.UnixAddr, payload []byte) error {
+	dir := "/dev/shm"
+	if _, err := os.Stat(dir); err != nil {
+		dir = ""
+	}
+	file, err := os.CreateTemp(dir, "journal-")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	if _, err := file.Write(payload); err != nil {
+		return err
+	}
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), addr)
+	return err
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package logger
+
+import (
+	"errors"
+	"net"
+)
+
+// isMessageTooLarge returns false on Windows, which has no journald.
+func isMessageTooLarge(error) bool {
+	return false
+}
+
+// sendJournalFile fails on Windows, which has no journald.
+func sendJournalFile(*net.UnixConn, *net.UnixAddr, []byte) error {
+	return errors.New("journald is not supported on windows")
+}
@@ -0,0 +1,222 @@
+//go:build !windows
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// listenJournal creates a stand-in for the native socket of journald.
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, shorter than some TempDirs.
+	dir, err := os.MkdirTemp("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, socket
+}
+
+// readJournalEntry reads an entry from the socket, following a passed file,
+// and decodes its fields. Fields may repeat, so their values are listed.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string][]string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("reading entry: %v", err)
+	}
+	payload := buf[:n]
+	if oobn > 0 {
+		payload = readJournalFile(t, oob[:oobn])
+	}
+	return decodeJournalEntry(t, payload)
+}
+
+// readJournalFile reads the payload of a file passed with the entry.
+func readJournalFile(t *testing.T, oob []byte) []byte {
+	t.Helper()
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("parsing control message: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("parsing passed descriptors: %v", err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "journal")
+	defer file.Close()
+	payload, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<30))
+	if err != nil {
+		t.Fatalf("reading passed file: %v", err)
+	}
+	return payload
+}
+
+// decodeJournalEntry decodes the native protocol: NAME=value lines, or a
+// NAME line followed by the little endian 64-bit length, the value and a newline.
+func decodeJournalEntry(t *testing.T, payload []byte) map[string][]string {
+	t.Helper()
+	fields := map[string][]string{}
+	for len(payload) > 0 {
+		line, rest, found := bytes.Cut(payload, []byte("\n"))
+		if !found {
+			t.Fatalf("field %q not terminated", payload)
+		}
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = append(fields[string(name)], string(value))
+			payload = rest
+			continue
+		}
+		if len(rest) < 8 {
+			t.Fatalf("field %s lacks its length", line)
+		}
+		size := binary.LittleEndian.Uint64(rest[:8])
+		if uint64(len(rest)) < 8+size+1 || rest[8+size] != '\n' {
+			t.Fatalf("field %s has an invalid length %d", line, size)
+		}
+		fields[string(line)] = append(fields[string(line)], string(rest[8:8+size]))
+		payload = rest[8+size+1:]
+	}
+	return fields
+}
+
+// journalField returns the only value of a field.
+func journalField(t *testing.T, fields map[string][]string, name string) string {
+	t.Helper()
+	values := fields[name]
+	if len(values) != 1 {
+		t.Fatalf("field %s has the values %q, want one", name, values)
+	}
+	return values[0]
+}
+
+func TestJournaldFields(t *testing.T) {
+	conn, socket := listenJournal(t)
+	j, err := NewJournald(JournaldConfig{Socket: socket, Identifier: "api", Facility: FacilityLocal1})
+	if err != nil {
+		t.Fatalf("NewJournald: %v", err)
+	}
+	defer j.Close()
+
+	l := newSinkLogger(t, WithJournald(j)).Named("db").With(zap.String("request_id", "r-1"))
+	l.Warn("query failed\nafter retries",
+		zap.Int("attempts", 3),
+		zap.String("message", "user message"),
+		zap.String("priority", "high"),
+		zap.String("query", "SELECT 1\nFROM t"),
+	)
+	fields := readJournalEntry(t, conn)
+
+	want := map[string]string{
+		"MESSAGE":           "query failed\nafter retries",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "api",
+		"SYSLOG_FACILITY":   "17",
+		"LOGGER":            "db",
+		"REQUEST_ID":        "r-1",
+		"ATTEMPTS":          "3",
+		"FIELD_MESSAGE":     "user message",
+		"FIELD_PRIORITY":    "high",
+		"QUERY":             "SELECT 1\nFROM t",
+	}
+	for name, value := range want {
+		if got := journalField(t, fields, name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if file := journalField(t, fields, "CODE_FILE"); !strings.HasSuffix(file, "journald_test.go") {
+		t.Errorf("CODE_FILE = %q", file)
+	}
+	if line := journalField(t, fields, "CODE_LINE"); strings.Trim(line, "0123456789") != "" {
+		t.Errorf("CODE_LINE = %q", line)
+	}
+}
+
+func TestJournaldCollidingFieldNames(t *testing.T) {
+	je := &journalEntry{names: map[string]bool{}}
+	je.add("MESSAGE", "entry")
+	for _, key := range []string{"message", "Message", "_message"} {
+		je.addField(key, key)
+	}
+	je.addField(strings.Repeat("x", 70), "long")
+	je.addField(strings.Repeat("X", 70), "long again")
+
+	fields := decodeJournalEntry(t, je.Bytes())
+	want := map[string]string{
+		"MESSAGE":                          "entry",
+		"FIELD_MESSAGE":                    "message",
+		"FIELD_MESSAGE_2":                  "Message",
+		"FIELD_MESSAGE_3":                  "_message",
+		strings.Repeat("X", 64):            "long",
+		"FIELD_" + strings.Repeat("X", 58): "long again",
+	}
+	if len(fields) != len(want) {
+		t.Errorf("got the fields %q, want %d", fields, len(want))
+	}
+	for name, value := range want {
+		if got := journalField(t, fields, name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestJournaldPassesLargeEntriesAsFiles(t *testing.T) {
+	conn, socket := listenJournal(t)
+	// A small receive buffer makes the datagram too large for the socket.
+	conn.SetReadBuffer(4096)
+	j, err := NewJournald(JournaldConfig{Socket: socket, Identifier: "api"})
+	if err != nil {
+		t.Fatalf("NewJournald: %v", err)
+	}
+	defer j.Close()
+
+	large := strings.Repeat("0123456789", 64*1024)
+	if err := j.send([]byte("MESSAGE=" + large + "\n")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	fields := readJournalEntry(t, conn)
+	if message := journalField(t, fields, "MESSAGE"); len(message) != len(large) {
+		t.Errorf("MESSAGE has %d bytes, want %d", len(message), len(large))
+	}
+}
+
+func TestJournaldPriority(t *testing.T) {
+	conn, socket := listenJournal(t)
+	j, err := NewJournald(JournaldConfig{Socket: socket})
+	if err != nil {
+		t.Fatalf("NewJournald: %v", err)
+	}
+	defer j.Close()
+	l := newSinkLogger(t, WithJournald(j))
+
+	for _, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.ErrorLevel} {
+		l.Zap().Log(level, "entry")
+		fields := readJournalEntry(t, conn)
+		if priority := journalField(t, fields, "PRIORITY"); priority != strconv.Itoa(SyslogSeverity(level)) {
+			t.Errorf("PRIORITY of %v = %s", level, priority)
+		}
+	}
+}
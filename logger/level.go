@@ -0,0 +1,272 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var errMissingLevel = errors.New("level is required")
+
+// TraceLevel logs more detail than Debug, e.g. the messages of a protocol.
+// Use the level encoders of this package to print it as "trace".
+const TraceLevel = zapcore.DebugLevel - 1
+
+// LevelName returns the lowercase name of a level, including "trace".
+func LevelName(level zapcore.Level) string {
+	if level == TraceLevel {
+		return "trace"
+	}
+	return level.String()
+}
+
+// LowercaseLevelEncoder is zapcore.LowercaseLevelEncoder with the Trace level.
+func LowercaseLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if level == TraceLevel {
+		enc.AppendString("trace")
+		return
+	}
+	zapcore.LowercaseLevelEncoder(level, enc)
+}
+
+// CapitalLevelEncoder is zapcore.CapitalLevelEncoder with the Trace level.
+func CapitalLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if level == TraceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalLevelEncoder(level, enc)
+}
+
+// CapitalColorLevelEncoder is zapcore.CapitalColorLevelEncoder with the Trace level in cyan.
+func CapitalColorLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if level == TraceLevel {
+		enc.AppendString("\x1b[36mTRACE\x1b[0m")
+		return
+	}
+	zapcore.CapitalColorLevelEncoder(level, enc)
+}
+
+// levelText encodes a level as text, including "trace".
+type levelText zapcore.Level
+
+func (lt levelText) MarshalText() ([]byte, error) {
+	return []byte(LevelName(zapcore.Level(lt))), nil
+}
+
+func (lt *levelText) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	*lt = levelText(level)
+	return err
+}
+
+// LevelController changes the level of a Logger at runtime.
+// A change can expire, after which the level reverts to the one set before
+// the temporary change. Every change is logged with who made it.
+type LevelController struct {
+	Identity
+
+	logger *Logger
+
+	mu        sync.Mutex
+	revert    *time.Timer
+	changes   int
+	expiresAt time.Time
+	previous  zapcore.Level
+}
+
+// NewLevelController creates a LevelController for the level of the Logger.
+func NewLevelController(l *Logger) *LevelController {
+	return &LevelController{logger: l}
+}
+
+// Level returns the current level.
+func (lc *LevelController) Level() zapcore.Level {
+	return lc.logger.Level().Level()
+}
+
+// SetLevel changes the level. With a positive expiry the level reverts
+// automatically once it has passed. The by value records who made the change.
+func (lc *LevelController) SetLevel(level zapcore.Level, expiry time.Duration, by string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	from := lc.Level()
+	lc.changes++
+	pending := lc.revert != nil
+	if pending {
+		lc.revert.Stop()
+		lc.revert = nil
+		lc.expiresAt = time.Time{}
+	}
+
+	if expiry > 0 {
+		if !pending {
+			lc.previous = from
+		}
+		lc.expiresAt = time.Now().Add(expiry)
+		change := lc.changes
+		lc.revert = time.AfterFunc(expiry, func() {
+			lc.expire(change)
+		})
+	}
+
+	lc.change(from, level, by)
+}
+
+// expire reverts the level after a temporary change,
+// unless the level has been changed again since.
+func (lc *LevelController) expire(change int) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if change != lc.changes || lc.revert == nil {
+		return
+	}
+	lc.changes++
+	lc.revert = nil
+	lc.expiresAt = time.Time{}
+	lc.change(lc.Level(), lc.previous, "expiry")
+}
+
+// change sets the level and logs the change.
+// The change is logged while the more verbose of both levels is active, so it
+// is visible unless both levels are above Info.
+func (lc *LevelController) change(from, to zapcore.Level, by string) {
+	fields := []zapcore.Field{
+		zap.String("from", LevelName(from)),
+		zap.String("to", LevelName(to)),
+		zap.String("by", by),
+		zap.Time("at", time.Now()),
+	}
+	if !lc.expiresAt.IsZero() {
+		fields = append(fields, zap.Time("expires_at", lc.expiresAt))
+	}
+
+	logLevel := zapcore.InfoLevel
+	if from > logLevel && to > logLevel {
+		logLevel = minLevel(minLevel(from, to), zapcore.ErrorLevel)
+	}
+	zl := lc.logger.Zap()
+
+	if to > from {
+		zl.Log(logLevel, "log level changed", fields...)
+		lc.logger.Level().SetLevel(to)
+		return
+	}
+	lc.logger.Level().SetLevel(to)
+	zl.Log(logLevel, "log level changed", fields...)
+}
+
+// levelPayload is the JSON document served and accepted by the LevelController.
+type levelPayload struct {
+	Level     *levelText `json:"level,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ServeHTTP serves the current level on GET and changes it on PUT.
+// The PUT body is a JSON document like {"level": "debug", "duration": "10m"},
+// the same values are also accepted as form values.
+func (lc *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := lc.update(r); err != nil {
+			writeLevelPayload(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelPayload(w, http.StatusMethodNotAllowed, levelPayload{Error: "only GET and PUT are supported"})
+		return
+	}
+
+	lc.mu.Lock()
+	level := lc.Level()
+	payload := levelPayload{Level: (*levelText)(&level)}
+	if !lc.expiresAt.IsZero() {
+		expiresAt := lc.expiresAt
+		payload.ExpiresAt = &expiresAt
+	}
+	lc.mu.Unlock()
+	writeLevelPayload(w, http.StatusOK, payload)
+}
+
+// update changes the level from a PUT request.
+func (lc *LevelController) update(r *http.Request) error {
+	var payload levelPayload
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		var level levelText
+		if err := level.UnmarshalText([]byte(r.FormValue("level"))); err != nil {
+			return err
+		}
+		payload.Level = &level
+		payload.Duration = r.FormValue("duration")
+	} else if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.Level == nil {
+		return errMissingLevel
+	}
+	var expiry time.Duration
+	if payload.Duration != "" {
+		var err error
+		if expiry, err = time.ParseDuration(payload.Duration); err != nil {
+			return err
+		}
+	}
+
+	lc.SetLevel(zapcore.Level(*payload.Level), expiry, lc.requester(r))
+	return nil
+}
+
+// Identity tells who sent a request changing a level, for logging the change.
+type Identity struct {
+	// Principal returns the authenticated user of a request, e.g. the subject
+	// of the claims of the auth middleware. It defaults to the basic auth user,
+	// which must be verified by a handler wrapping the level handler.
+	Principal func(r *http.Request) string
+	// TrustedProxyHeader names a header with the user authenticated by a
+	// proxy, e.g. X-Forwarded-User. Clients can send any header, so only set
+	// it when every request passes through the proxy.
+	TrustedProxyHeader string
+}
+
+// requester describes who sent the request, preferring an authenticated user name.
+func (id Identity) requester(r *http.Request) string {
+	var user string
+	switch {
+	case id.TrustedProxyHeader != "" && r.Header.Get(id.TrustedProxyHeader) != "":
+		user = r.Header.Get(id.TrustedProxyHeader)
+	case id.Principal != nil:
+		user = id.Principal(r)
+	default:
+		user, _, _ = r.BasicAuth()
+	}
+	if user == "" {
+		return r.RemoteAddr
+	}
+	return user + "@" + r.RemoteAddr
+}
+
+// writeLevelPayload sends the payload as JSON.
+func writeLevelPayload(w http.ResponseWriter, code int, payload levelPayload) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// minLevel returns the more verbose of both levels.
+func minLevel(a, b zapcore.Level) zapcore.Level {
+	if a < b {
+		return a
+	}
+	return b
+}
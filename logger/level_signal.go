@@ -0,0 +1,50 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HandleSignals steps the level on signals: SIGUSR1 makes the Logger more
+// verbose and SIGUSR2 less verbose, between Debug and Fatal.
+// The returned function stops handling the signals.
+func (lc *LevelController) HandleSignals() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 {
+					lc.step(true, "signal SIGUSR1")
+				} else {
+					lc.step(false, "signal SIGUSR2")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// step lowers or raises the level by one.
+func (lc *LevelController) step(verbose bool, by string) {
+	level := lc.Level()
+	if verbose && level > TraceLevel {
+		lc.SetLevel(level-1, 0, by)
+	} else if !verbose && level < zapcore.FatalLevel {
+		lc.SetLevel(level+1, 0, by)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package logger
+
+// HandleSignals does nothing on Windows, which has no SIGUSR1 and SIGUSR2.
+func (lc *LevelController) HandleSignals() (stop func()) {
+	return func() {}
+}
@@ -1,13 +1,18 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var logger *zap.Logger
+// defaultLogger is the Logger used by the package-level functions.
+var defaultLogger atomic.Pointer[Logger]
 
 var DefaultConfig = zap.Config{
 	Encoding:         "console",
@@ -17,7 +22,8 @@ var DefaultConfig = zap.Config{
 	EncoderConfig: zapcore.EncoderConfig{
 		MessageKey:   "message",
 		LevelKey:     "level",
-		EncodeLevel:  zapcore.CapitalLevelEncoder,
+		NameKey:      "logger",
+		EncodeLevel:  CapitalLevelEncoder,
 		TimeKey:      "time",
 		EncodeTime:   zapcore.ISO8601TimeEncoder,
 		CallerKey:    "caller",
@@ -25,57 +31,99 @@ var DefaultConfig = zap.Config{
 	},
 }
 
+// InitializeLogger builds a Logger from the config and makes it the default.
+// It panics if the config is invalid.
 func InitializeLogger(cfg zap.Config) {
-	var err error
-	logger, err = cfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+	l, err := New(cfg)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 	}
+	SetDefault(l)
 }
 
 func init() {
 	InitializeLogger(DefaultConfig)
 }
 
+// Default returns the Logger used by the package-level functions.
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+// SetDefault atomically replaces the Logger used by the package-level functions.
+// It is safe to call while other goroutines are logging.
+// The returned function restores the previous default.
+func SetDefault(l *Logger) (restore func()) {
+	previous := defaultLogger.Swap(l)
+	return func() {
+		defaultLogger.Store(previous)
+	}
+}
+
+// Trace logs a message at level Trace on the standard logger.
+// log.Trace("This is a TRACE message")
+func Trace(msg string, fields ...zapcore.Field) {
+	Default().zap.Log(TraceLevel, msg, fields...)
+}
+
 // Debug logs a message at level Debug on the standard logger.
 // log.Debug("This is a DEBUG message")
 func Debug(msg string, fields ...zapcore.Field) {
-	logger.Debug(msg, fields...)
+	Default().zap.Debug(msg, fields...)
 }
 
 // Info logs a message at level Info on the standard logger.
 // log.Info("This is an INFO message")
 // log.Info("This is an INFO message with fields", zap.String("region", "APAC"), zap.Int("id", 1))
 func Info(msg string, fields ...zapcore.Field) {
-	logger.Info(msg, fields...)
+	Default().zap.Info(msg, fields...)
 }
 
 // Warn logs a message at level Warn on the standard logger.
 // log.Warn("This is a Warn message")
 func Warn(msg string, fields ...zapcore.Field) {
-	logger.Warn(msg, fields...)
+	Default().zap.Warn(msg, fields...)
 }
 
 // Error logs a message at level Error on the standard logger.
 // log.Error("This is an ERROR message")
 func Error(msg string, fields ...zapcore.Field) {
-	logger.Error(msg, fields...)
+	Default().zap.Error(msg, fields...)
+}
+
+// DPanic logs a message at level DPanic on the standard logger.
+// In development, it then panics.
+func DPanic(msg string, fields ...zapcore.Field) {
+	Default().zap.DPanic(msg, fields...)
+}
+
+// Panic logs a message at level Panic on the standard logger.
+// After logging, it panics.
+func Panic(msg string, fields ...zapcore.Field) {
+	Default().zap.Panic(msg, fields...)
 }
 
 // Fatal logs a message at level Fatal on the standard logger.
 // After logging, it will call os.Exit(1).
 // log.Fatal("This is a FATAL message")
 func Fatal(msg string, fields ...zapcore.Field) {
-	logger.Fatal(msg, fields...)
+	Default().zap.Fatal(msg, fields...)
 }
 
+// cleanupTimeout bounds how long Cleanup waits for the log entries to be written.
+const cleanupTimeout = 5 * time.Second
+
 // Cleanup flushes all log entries.
 // The reason is certain loggers might not write each log message to its destination immediately upon receiving it.
 // The logger could accumulate several log messages in memory and then write them out in a single batch.
 // So call Cleanup before the application exits to make sure all buffered logs are properly written.
 // E.g. defer log.Cleanup()
+//
+// Cleanup runs Shutdown with a five second deadline and reports failures on stderr.
 func Cleanup() {
-	if err := logger.Sync(); err != nil {
-		panic(fmt.Sprintf("Failed to sync logger: %v", err))
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	if err := Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to sync logger: %v\n", err)
 	}
 }
@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a structured logger writing through zap.
+// Loggers are safe for concurrent use, and With and Named return new
+// loggers sharing the outputs of their parent.
+type Logger struct {
+	// zap skips one frame so that the caller of the Logger methods is reported.
+	zap   *zap.Logger
+	level zap.AtomicLevel
+}
+
+// Option configures a Logger built with New.
+type Option func(*options)
+
+// options are the settings collected from the Options passed to New.
+type options struct {
+	configure []func(cfg *zap.Config)
+	// tees are extra outputs written next to the outputs of the config.
+	// They are given the config and the level of the Logger.
+	tees []func(cfg zap.Config, level zapcore.LevelEnabler) zapcore.Core
+	// rewrite wraps the output core with cores rewriting the fields. They are
+	// applied before zapOptions, so that sampling and deduplication see the
+	// entries as the user logged them.
+	rewrite []func(core zapcore.Core) zapcore.Core
+	// errorStacks are the levels at which the stacks of errors are logged.
+	errorStacks     zapcore.LevelEnabler
+	zapOptions      []zap.Option
+	componentLevels *ComponentLevels
+}
+
+// WithZapOptions passes zap options, e.g. zap.Fields or zap.WrapCore, to the zap logger.
+func WithZapOptions(opts ...zap.Option) Option {
+	return func(o *options) {
+		o.zapOptions = append(o.zapOptions, opts...)
+	}
+}
+
+// New builds a new Logger from the config.
+func New(cfg zap.Config, opts ...Option) (*Logger, error) {
+	o := options{errorStacks: zapcore.ErrorLevel}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, configure := range o.configure {
+		configure(&cfg)
+	}
+
+	level := cfg.Level
+	teeConfig := cfg
+	teeLevel := zapcore.LevelEnabler(level)
+	if o.componentLevels != nil {
+		// The component levels wrap the tees too, and filter their entries.
+		teeLevel = allLevels
+	}
+	if cfg.Encoding == DevEncoding && devColorOutputs(cfg.OutputPaths) {
+		cfg.Encoding = devColorEncoding
+	}
+	zapOptions := []zap.Option{zap.WithCaller(!cfg.DisableCaller), zap.AddCallerSkip(1)}
+	if len(o.tees) > 0 {
+		zapOptions = append(zapOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			cores := []zapcore.Core{core}
+			for _, tee := range o.tees {
+				cores = append(cores, tee(teeConfig, teeLevel))
+			}
+			return zapcore.NewTee(cores...)
+		}))
+	}
+	for _, rewrite := range o.rewrite {
+		zapOptions = append(zapOptions, zap.WrapCore(rewrite))
+	}
+	// The errors are mapped first, so that the rewrite cores see their chains.
+	readable := cfg.Encoding != "json" && cfg.EncoderConfig.StacktraceKey != ""
+	zapOptions = append(zapOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &errorCore{Core: core, stacks: o.errorStacks, readable: readable}
+	}))
+	zapOptions = append(zapOptions, o.zapOptions...)
+	if o.componentLevels != nil {
+		// The component levels wrap the other cores, so that they only see enabled entries.
+		cfg.Level = zap.NewAtomicLevelAt(allLevels)
+		zapOptions = append(zapOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &componentCore{Core: core, levels: o.componentLevels}
+		}))
+	}
+
+	zl, err := cfg.Build(zapOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{zap: zl, level: level}, nil
+}
+
+// NewFromZap creates a Logger writing through an existing zap logger.
+// The level of the Logger starts at the level of zl and can only be raised above it.
+func NewFromZap(zl *zap.Logger) *Logger {
+	level := zap.NewAtomicLevelAt(zl.Level())
+	zl = zl.WithOptions(
+		zap.AddCallerSkip(1),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &levelCore{Core: core, level: level}
+		}),
+	)
+	return &Logger{zap: zl, level: level}
+}
+
+// Zap returns the underlying zap logger, reporting its own callers.
+func (l *Logger) Zap() *zap.Logger {
+	return l.zap.WithOptions(zap.AddCallerSkip(-1))
+}
+
+// With creates a child Logger adding the fields to every log line.
+func (l *Logger) With(fields ...zapcore.Field) *Logger {
+	return &Logger{zap: l.zap.With(fields...), level: l.level}
+}
+
+// Named creates a child Logger with the name appended to the logger name.
+// Names are joined with periods, e.g. "http.client".
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{zap: l.zap.Named(name), level: l.level}
+}
+
+// WithCallerSkip creates a child Logger skipping skip more frames when
+// reporting the caller. Use it when wrapping the Logger in helper functions.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	return &Logger{zap: l.zap.WithOptions(zap.AddCallerSkip(skip)), level: l.level}
+}
+
+// WrapCore creates a child Logger writing through the core returned by wrap,
+// e.g. to tee its entries into another core.
+func (l *Logger) WrapCore(wrap func(core zapcore.Core) zapcore.Core) *Logger {
+	return &Logger{zap: l.zap.WithOptions(zap.WrapCore(wrap)), level: l.level}
+}
+
+// Level returns the level shared by the Logger, its children and its parent.
+func (l *Logger) Level() zap.AtomicLevel {
+	return l.level
+}
+
+// Enabled returns true if the Logger could log at the level.
+func (l *Logger) Enabled(level zapcore.Level) bool {
+	return l.zap.Core().Enabled(level)
+}
+
+// Trace logs a message at level Trace.
+func (l *Logger) Trace(msg string, fields ...zapcore.Field) {
+	l.zap.Log(TraceLevel, msg, fields...)
+}
+
+// Debug logs a message at level Debug.
+func (l *Logger) Debug(msg string, fields ...zapcore.Field) {
+	l.zap.Debug(msg, fields...)
+}
+
+// Info logs a message at level Info.
+func (l *Logger) Info(msg string, fields ...zapcore.Field) {
+	l.zap.Info(msg, fields...)
+}
+
+// Warn logs a message at level Warn.
+func (l *Logger) Warn(msg string, fields ...zapcore.Field) {
+	l.zap.Warn(msg, fields...)
+}
+
+// Error logs a message at level Error.
+func (l *Logger) Error(msg string, fields ...zapcore.Field) {
+	l.zap.Error(msg, fields...)
+}
+
+// DPanic logs a message at level DPanic. In development it then panics.
+func (l *Logger) DPanic(msg string, fields ...zapcore.Field) {
+	l.zap.DPanic(msg, fields...)
+}
+
+// Panic logs a message at level Panic and then panics.
+func (l *Logger) Panic(msg string, fields ...zapcore.Field) {
+	l.zap.Panic(msg, fields...)
+}
+
+// Fatal logs a message at level Fatal and then calls os.Exit(1).
+func (l *Logger) Fatal(msg string, fields ...zapcore.Field) {
+	l.zap.Fatal(msg, fields...)
+}
+
+// Sync flushes any buffered log entries.
+func (l *Logger) Sync() error {
+	return l.zap.Sync()
+}
+
+// levelCore filters the entries of a core below a level that can change at runtime.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (lc *levelCore) Enabled(level zapcore.Level) bool {
+	return lc.level.Enabled(level) && lc.Core.Enabled(level)
+}
+
+func (lc *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: lc.Core.With(fields), level: lc.level}
+}
+
+func (lc *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !lc.level.Enabled(entry.Level) {
+		return checked
+	}
+	return lc.Core.Check(entry, checked)
+}
+
+// rewriter is a core rewriting the entries written to the core it wraps.
+type rewriter interface {
+	zapcore.Core
+	rewrite(entry zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field)
+}
+
+// checkRewrite checks the entry against the core wrapped by the rewriter,
+// which samples or filters it, and adds the rewriter if the wrapped core
+// accepts the entry.
+func checkRewrite(rw rewriter, inner zapcore.Core, entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !rw.Enabled(entry.Level) {
+		return checked
+	}
+	innerChecked := inner.Check(entry, nil)
+	if innerChecked == nil {
+		return checked
+	}
+	re := &rewriteEntry{rewriter: rw, inner: innerChecked}
+	checked = checked.AddCore(entry, re)
+	re.outer = checked
+	return checked
+}
+
+// rewriteEntry writes a rewritten entry through the entry checked by the
+// wrapped core, so that it reaches only the cores accepting it.
+type rewriteEntry struct {
+	rewriter
+	inner *zapcore.CheckedEntry
+	outer *zapcore.CheckedEntry
+}
+
+func (re *rewriteEntry) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// The caller and stack are added to the outer entry after Check.
+	re.inner.Entry, fields = re.rewrite(entry, fields)
+	re.inner.ErrorOutput = re.outer.ErrorOutput
+	re.inner.Write(fields...)
+	return nil
+}
@@ -0,0 +1,350 @@
+// Package logtest captures the log entries written during a test, through
+// the logger package functions and the tracer/log package, to assert on them.
+//
+//	func TestHandler(t *testing.T) {
+//		logs := logtest.Observe(t)
+//		handler.ServeHTTP(w, r)
+//		logs.Entries().Level(zapcore.ErrorLevel).Message("payment failed").
+//			Field("order_id", "o-1").AssertCount(1)
+//	}
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+)
+
+// Observer keeps the entries logged while it is active.
+type Observer struct {
+	t    testing.TB
+	core zapcore.Core
+	logs *observer.ObservedLogs
+	// observed is set for the Observers of the default Logger, and reported
+	// once it has been replaced.
+	observed bool
+	reported atomic.Bool
+}
+
+// newObserver creates an Observer capturing every level.
+func newObserver(t testing.TB) *Observer {
+	core, logs := observer.New(zap.NewAtomicLevelAt(log.TraceLevel))
+	return &Observer{t: t, core: core, logs: logs}
+}
+
+// installed is the state shared by the active Observers of the default Logger.
+var installed struct {
+	sync.RWMutex
+	observers map[*Observer]bool
+	logger    *log.Logger
+	restore   func()
+}
+
+// Observe captures the entries written to the default Logger until the end
+// of the test. The default Logger keeps writing to its outputs, and is
+// restored when the last active Observer is done.
+//
+// The default Logger is shared by the whole process, so Observe fails tests
+// calling t.Parallel, and keeps them from calling it later: inject the Logger
+// of NewLogger into parallel tests instead. Observe also fails the test if the
+// default Logger is replaced while it is observed, e.g. with SetDefault or
+// Configure, since the entries of the new default are not captured.
+// Loggers derived from the default before Observe, e.g. with Named, are not
+// observed.
+//
+// Only the entries the default Logger writes are captured, and not those
+// below its level or dropped by its sampling. The entries are captured with
+// the fields as logged: redaction and the other options of the Logger
+// rewriting entries are not applied to them.
+func Observe(t testing.TB) *Observer {
+	t.Helper()
+	denyParallel(t)
+	o := newObserver(t)
+	o.observed = true
+
+	installed.Lock()
+	if installed.observers == nil {
+		installed.observers = map[*Observer]bool{}
+	}
+	installed.observers[o] = true
+	if len(installed.observers) == 1 {
+		installed.logger = log.Default().WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &observeCore{Core: core}
+		})
+		installed.restore = log.SetDefault(installed.logger)
+	}
+	installed.Unlock()
+
+	t.Cleanup(func() {
+		o.checkDefault()
+		installed.Lock()
+		defer installed.Unlock()
+		delete(installed.observers, o)
+		if len(installed.observers) > 0 {
+			return
+		}
+		// Keep a default set by the test itself.
+		if log.Default() == installed.logger {
+			installed.restore()
+		}
+		installed.logger, installed.restore = nil, nil
+	})
+	return o
+}
+
+// parallelEnv is set by Observe for its test. Setenv panics in parallel tests,
+// and makes t.Parallel panic afterwards.
+const parallelEnv = "LOGTEST_OBSERVE"
+
+// denyParallel fails parallel tests, and keeps the test from becoming one.
+func denyParallel(t testing.TB) {
+	t.Helper()
+	parallel := func() (parallel bool) {
+		defer func() {
+			parallel = recover() != nil
+		}()
+		t.Setenv(parallelEnv, "1")
+		return false
+	}()
+	if parallel {
+		t.Fatal("logtest: Observe captures the default Logger, shared by parallel tests: use NewLogger")
+	}
+}
+
+// checkDefault reports a failure if the default Logger observed was replaced.
+func (o *Observer) checkDefault() {
+	o.t.Helper()
+	if !o.observed {
+		return
+	}
+	installed.RLock()
+	replaced := installed.logger != nil && log.Default() != installed.logger
+	installed.RUnlock()
+	if replaced && o.reported.CompareAndSwap(false, true) {
+		o.t.Errorf("logtest: the default Logger was replaced after Observe, and its entries are not captured: call Observe after replacing it")
+	}
+}
+
+// NewLogger creates a Logger writing only to a new Observer, to inject into
+// the code under test. It is isolated from other tests.
+func NewLogger(t testing.TB) (*log.Logger, *Observer) {
+	o := newObserver(t)
+	return log.NewFromZap(zap.New(o.core, zap.AddCaller())), o
+}
+
+// Entries returns the entries captured so far.
+func (o *Observer) Entries() Entries {
+	o.t.Helper()
+	o.checkDefault()
+	return Entries{t: o.t, entries: o.logs.All()}
+}
+
+// Len returns the number of entries captured so far.
+func (o *Observer) Len() int {
+	o.t.Helper()
+	o.checkDefault()
+	return o.logs.Len()
+}
+
+// Reset drops the entries captured so far.
+func (o *Observer) Reset() {
+	o.logs.TakeAll()
+}
+
+// observeCore writes the entries of the default Logger to the active
+// Observers once the core it wraps has accepted them.
+type observeCore struct {
+	zapcore.Core
+	fields []zapcore.Field
+}
+
+func (oc *observeCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(oc.fields)+len(fields))
+	combined = append(combined, oc.fields...)
+	return &observeCore{Core: oc.Core.With(fields), fields: append(combined, fields...)}
+}
+
+func (oc *observeCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := oc.Core.Check(entry, nil)
+	if inner == nil {
+		return checked
+	}
+	oe := &observedEntry{observeCore: oc, inner: inner}
+	checked = checked.AddCore(entry, oe)
+	oe.outer = checked
+	return checked
+}
+
+// observedEntry writes an entry through the entry checked by the wrapped
+// core, and then to the active Observers.
+type observedEntry struct {
+	*observeCore
+	inner *zapcore.CheckedEntry
+	outer *zapcore.CheckedEntry
+}
+
+func (oe *observedEntry) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// The caller and stack are added to the outer entry after Check.
+	oe.inner.Entry = entry
+	oe.inner.ErrorOutput = oe.outer.ErrorOutput
+	oe.inner.Write(fields...)
+
+	installed.RLock()
+	defer installed.RUnlock()
+	for o := range installed.observers {
+		o.core.With(oe.fields).Write(entry, fields)
+	}
+	return nil
+}
+
+// Entries is a list of captured entries. Its filters return the matching
+// entries, and its assertions report failures to the test.
+type Entries struct {
+	t       testing.TB
+	entries []observer.LoggedEntry
+	// filters describe the filters applied, for the failure messages.
+	filters []string
+}
+
+// All returns the entries.
+func (e Entries) All() []observer.LoggedEntry {
+	return e.entries
+}
+
+// Len returns the number of entries.
+func (e Entries) Len() int {
+	return len(e.entries)
+}
+
+// Filter returns the entries for which keep returns true.
+func (e Entries) Filter(description string, keep func(entry observer.LoggedEntry) bool) Entries {
+	filtered := Entries{t: e.t, filters: append(e.filters[:len(e.filters):len(e.filters)], description)}
+	for _, entry := range e.entries {
+		if keep(entry) {
+			filtered.entries = append(filtered.entries, entry)
+		}
+	}
+	return filtered
+}
+
+// Level returns the entries at the level.
+func (e Entries) Level(level zapcore.Level) Entries {
+	return e.Filter("level "+log.LevelName(level), func(entry observer.LoggedEntry) bool {
+		return entry.Level == level
+	})
+}
+
+// MinLevel returns the entries at the level or above.
+func (e Entries) MinLevel(level zapcore.Level) Entries {
+	return e.Filter("level >= "+log.LevelName(level), func(entry observer.LoggedEntry) bool {
+		return entry.Level >= level
+	})
+}
+
+// Message returns the entries with the message.
+func (e Entries) Message(msg string) Entries {
+	return e.Filter(fmt.Sprintf("message %q", msg), func(entry observer.LoggedEntry) bool {
+		return entry.Message == msg
+	})
+}
+
+// MessageContains returns the entries with a message containing the substring.
+func (e Entries) MessageContains(substring string) Entries {
+	return e.Filter(fmt.Sprintf("message containing %q", substring), func(entry observer.LoggedEntry) bool {
+		return strings.Contains(entry.Message, substring)
+	})
+}
+
+// Field returns the entries with a field of the key and value. The value is
+// compared as encoded, so that e.g. an int matches a zap.Int64 field.
+func (e Entries) Field(key string, value interface{}) Entries {
+	expected := zapcore.NewMapObjectEncoder()
+	zap.Any(key, value).AddTo(expected)
+	return e.Filter(fmt.Sprintf("field %s=%v", key, value), func(entry observer.LoggedEntry) bool {
+		actual, exists := entry.ContextMap()[key]
+		return exists && reflect.DeepEqual(actual, expected.Fields[key])
+	})
+}
+
+// HasField returns the entries with a field of the key.
+func (e Entries) HasField(key string) Entries {
+	return e.Filter("field "+key, func(entry observer.LoggedEntry) bool {
+		_, exists := entry.ContextMap()[key]
+		return exists
+	})
+}
+
+// TraceID returns the entries logged with the trace of the ID, e.g. through
+// tracer/log with the context of a traced request.
+func (e Entries) TraceID(traceID string) Entries {
+	return e.Filter("trace ID "+traceID, func(entry observer.LoggedEntry) bool {
+		return TraceIDOf(entry) == traceID
+	})
+}
+
+// TraceIDOf returns the trace ID of an entry, or "" if it has no trace.
+func TraceIDOf(entry observer.LoggedEntry) string {
+	trace, _ := entry.ContextMap()[log.TraceFieldKey].(map[string]interface{})
+	traceID, _ := trace["trace_id"].(string)
+	return traceID
+}
+
+// AssertCount reports a failure unless there are count entries.
+func (e Entries) AssertCount(count int) bool {
+	e.t.Helper()
+	if len(e.entries) == count {
+		return true
+	}
+	e.t.Errorf("expected %d log entries with %s, got %d%s", count, e.description(), len(e.entries), e.dump())
+	return false
+}
+
+// AssertLogged reports a failure if there are no entries.
+func (e Entries) AssertLogged() bool {
+	e.t.Helper()
+	if len(e.entries) > 0 {
+		return true
+	}
+	e.t.Errorf("expected log entries with %s, got none", e.description())
+	return false
+}
+
+// AssertNone reports a failure if there are entries.
+func (e Entries) AssertNone() bool {
+	e.t.Helper()
+	if len(e.entries) == 0 {
+		return true
+	}
+	e.t.Errorf("expected no log entries with %s, got %d%s", e.description(), len(e.entries), e.dump())
+	return false
+}
+
+// description lists the filters applied.
+func (e Entries) description() string {
+	if len(e.filters) == 0 {
+		return "any content"
+	}
+	return strings.Join(e.filters, ", ")
+}
+
+// dump formats the entries for a failure message.
+func (e Entries) dump() string {
+	if len(e.entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(":")
+	for _, entry := range e.entries {
+		fmt.Fprintf(&b, "\n\t%s %q %v", log.LevelName(entry.Level), entry.Message, entry.ContextMap())
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package logtest
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+	"github.com/jimxshaw/tracerlogger/tracer"
+	tlog "github.com/jimxshaw/tracerlogger/tracer/log"
+)
+
+func TestObserve(t *testing.T) {
+	cfg := log.DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	cfg.OutputPaths = nil
+	l, err := log.New(cfg, log.WithRedaction(log.NewRedactor()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(log.SetDefault(l))
+
+	logs := Observe(t)
+	log.Debug("not written")
+	log.Default().With(zap.String("order_id", "o-1")).Info("created", zap.String("password", "hunter2"))
+	trace := tracer.NewTracerContext()
+	tlog.Warn(tracer.InjectInCtx(context.Background(), trace), "slow")
+
+	logs.Entries().Level(zapcore.DebugLevel).AssertNone()
+	// The fields are captured as logged, without the redaction of the Logger.
+	logs.Entries().Message("created").Field("order_id", "o-1").Field("password", "hunter2").AssertCount(1)
+	logs.Entries().TraceID(trace.Trace.String()).Message("slow").AssertCount(1)
+	if logs.Len() != 2 {
+		t.Errorf("captured %d entries, want 2", logs.Len())
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+	l, logs := NewLogger(t)
+	l.Named("db").Trace("query", zap.Int("rows", 3))
+	logs.Entries().Level(log.TraceLevel).Field("rows", 3).AssertCount(1)
+	if entry := logs.Entries().All()[0]; entry.LoggerName != "db" || !entry.Caller.Defined {
+		t.Errorf("unexpected entry %+v", entry.Entry)
+	}
+}
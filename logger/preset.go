@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Names of the presets returned by Preset.
+const (
+	PresetDevelopment = "development"
+	PresetProduction  = "production"
+	PresetTest        = "test"
+)
+
+// DevelopmentConfig is the developer console encoder at Debug, colored in
+// terminals, for laptops.
+func DevelopmentConfig() zap.Config {
+	cfg := DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	cfg.Development = true
+	cfg.Encoding = DevEncoding
+	cfg.EncoderConfig.StacktraceKey = "stacktrace"
+	return cfg
+}
+
+// ProductionConfig is a JSON encoder at Info with sampling, for log platforms.
+func ProductionConfig() zap.Config {
+	cfg := DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	cfg.Encoding = "json"
+	cfg.Sampling = &zap.SamplingConfig{
+		Initial:    100,
+		Thereafter: 100,
+	}
+	cfg.EncoderConfig.EncodeLevel = LowercaseLevelEncoder
+	cfg.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	cfg.EncoderConfig.EncodeDuration = zapcore.MillisDurationEncoder
+	cfg.EncoderConfig.StacktraceKey = "stacktrace"
+	return cfg
+}
+
+// TestConfig is a console encoder at Debug without timestamps, so test output is stable.
+func TestConfig() zap.Config {
+	cfg := DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	cfg.Development = true
+	cfg.DisableStacktrace = true
+	cfg.EncoderConfig.TimeKey = zapcore.OmitKey
+	return cfg
+}
+
+// Preset returns the config of a named preset: development, production or test.
+func Preset(name string) (zap.Config, error) {
+	switch name {
+	case PresetDevelopment:
+		return DevelopmentConfig(), nil
+	case PresetProduction:
+		return ProductionConfig(), nil
+	case PresetTest:
+		return TestConfig(), nil
+	default:
+		return zap.Config{}, fmt.Errorf("unknown preset %q", name)
+	}
+}
@@ -0,0 +1,444 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MaskStyle is how a Redactor masks a value.
+type MaskStyle int
+
+const (
+	// MaskFull replaces the value with "[REDACTED]".
+	MaskFull MaskStyle = iota
+	// MaskPartial keeps the last four characters of a ValuePattern match, or
+	// the first letter and the domain of an email address. Values of matching
+	// keys are masked fully, as their shape is unknown.
+	MaskPartial
+	// MaskHash replaces the value with a keyed hash, so that equal values
+	// can still be correlated.
+	MaskHash
+)
+
+// redactedText replaces fully masked values.
+const redactedText = "[REDACTED]"
+
+// Patterns used by the default redaction rules.
+var (
+	SecretKeyPattern  = regexp.MustCompile(`(?i)passw(or)?d|secret|token|api[_-]?key|authorization|cookie|credential|private[_-]?key`)
+	JWTPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	CardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+)
+
+// RedactRule selects the values a Redactor masks.
+// Key and KeyPattern mask the whole value of matching keys, at any depth.
+// ValuePattern masks the matches within string values, error messages and
+// log messages.
+type RedactRule struct {
+	Key          string
+	KeyPattern   *regexp.Regexp
+	ValuePattern *regexp.Regexp
+	// Validate, if set, confirms a ValuePattern match, e.g. by a checksum.
+	Validate func(match string) bool
+	Style    MaskStyle
+}
+
+// DefaultRedactRules mask secrets by key name, and JWTs, card numbers and
+// email addresses by value.
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{KeyPattern: SecretKeyPattern, Style: MaskFull},
+		{ValuePattern: JWTPattern, Style: MaskFull},
+		{ValuePattern: CardNumberPattern, Validate: luhnValid, Style: MaskPartial},
+		{ValuePattern: EmailPattern, Style: MaskPartial},
+	}
+}
+
+// Redactor masks sensitive values in log fields.
+//
+// Objects, arrays and values logged with zap.Any are encoded to find nested
+// values, so every such field costs an extra encoding.
+type Redactor struct {
+	Rules []RedactRule
+	// HashKey is the HMAC key of MaskHash. Set it to keep hashes from being
+	// reversed by hashing guessed values.
+	HashKey []byte
+}
+
+// NewRedactor creates a Redactor with the rules, or with DefaultRedactRules if there are none.
+func NewRedactor(rules ...RedactRule) *Redactor {
+	if len(rules) == 0 {
+		rules = DefaultRedactRules()
+	}
+	return &Redactor{Rules: rules}
+}
+
+// WithRedaction masks the fields and messages of the Logger with the Redactor.
+func WithRedaction(r *Redactor) Option {
+	return func(o *options) {
+		o.rewrite = append(o.rewrite, func(core zapcore.Core) zapcore.Core {
+			return &redactCore{Core: core, redactor: r}
+		})
+	}
+}
+
+// Redact returns the fields with the sensitive values masked.
+// The slice is only copied when a field is masked.
+func (r *Redactor) Redact(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		masked, changed := r.redactField(field)
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = append(make([]zapcore.Field, 0, len(fields)), fields...)
+		}
+		redacted[i] = masked
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// RedactString masks the matches of the value rules in the text.
+func (r *Redactor) RedactString(text string) string {
+	redacted, _ := r.redactString(text)
+	return redacted
+}
+
+// redactField masks a field, reporting whether it changed.
+func (r *Redactor) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	switch field.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return field, false
+	}
+	if rule := r.keyRule(field.Key); rule != nil {
+		return zap.String(field.Key, r.maskValue(fieldValue(field), rule.Style)), true
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		if text, changed := r.redactString(field.String); changed {
+			return zap.String(field.Key, text), true
+		}
+	case zapcore.ByteStringType:
+		if text, changed := r.redactString(string(field.Interface.([]byte))); changed {
+			return zap.String(field.Key, text), true
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			if text, changed := r.redactString(err.Error()); changed {
+				return zap.NamedError(field.Key, errors.New(text)), true
+			}
+		}
+	case zapcore.StringerType, zapcore.ReflectType,
+		zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		if value, changed := r.walk(fieldValue(field)); changed {
+			if field.Type == zapcore.InlineMarshalerType {
+				if object, ok := value.(map[string]interface{}); ok {
+					return zap.Inline(redactedObject(object)), true
+				}
+			}
+			return valueField(field.Key, value), true
+		}
+	}
+	return field, false
+}
+
+// keyRule returns the first key rule matching the key.
+func (r *Redactor) keyRule(key string) *RedactRule {
+	if key == "" {
+		return nil
+	}
+	for i, rule := range r.Rules {
+		if rule.Key != "" && strings.EqualFold(rule.Key, key) ||
+			rule.KeyPattern != nil && rule.KeyPattern.MatchString(key) {
+			return &r.Rules[i]
+		}
+	}
+	return nil
+}
+
+// redactString masks the matches of the value rules, reporting whether the text changed.
+func (r *Redactor) redactString(text string) (string, bool) {
+	redacted := text
+	for _, rule := range r.Rules {
+		if rule.ValuePattern == nil {
+			continue
+		}
+		redacted = rule.ValuePattern.ReplaceAllStringFunc(redacted, func(match string) string {
+			if rule.Validate != nil && !rule.Validate(match) {
+				return match
+			}
+			return r.mask(match, rule.Style)
+		})
+	}
+	return redacted, redacted != text
+}
+
+// walk masks the values of an encoded field, reporting whether any changed.
+func (r *Redactor) walk(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return r.redactString(value)
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		changed := false
+		for key, item := range value {
+			if rule := r.keyRule(key); rule != nil {
+				redacted[key] = r.maskValue(item, rule.Style)
+				changed = true
+				continue
+			}
+			var itemChanged bool
+			redacted[key], itemChanged = r.walk(item)
+			changed = changed || itemChanged
+		}
+		return redacted, changed
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		changed := false
+		for i, item := range value {
+			var itemChanged bool
+			redacted[i], itemChanged = r.walk(item)
+			changed = changed || itemChanged
+		}
+		return redacted, changed
+	}
+	return value, false
+}
+
+// maskValue masks the whole value of a matching key.
+func (r *Redactor) maskValue(value interface{}, style MaskStyle) string {
+	if style != MaskHash {
+		return redactedText
+	}
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return redactedText
+		}
+		text = string(data)
+	}
+	return r.mask(text, style)
+}
+
+// mask masks a value in the style.
+func (r *Redactor) mask(value string, style MaskStyle) string {
+	switch style {
+	case MaskPartial:
+		if isEmail(value) {
+			return value[:1] + "***" + value[strings.LastIndexByte(value, '@'):]
+		}
+		runes := []rune(value)
+		if len(runes) < 8 {
+			return redactedText
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case MaskHash:
+		mac := hmac.New(sha256.New, r.HashKey)
+		mac.Write([]byte(value))
+		return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	default:
+		return redactedText
+	}
+}
+
+// isEmail reports whether the whole value is an email address.
+func isEmail(value string) bool {
+	loc := EmailPattern.FindStringIndex(value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(value)
+}
+
+// fieldValue encodes a field into maps, slices and plain values.
+func fieldValue(field zapcore.Field) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	if field.Type == zapcore.InlineMarshalerType {
+		return normalize(enc.Fields)
+	}
+	return normalize(enc.Fields[field.Key])
+}
+
+// normalize copies an encoded value, converting the values added with
+// AddReflected through JSON so that their nested values can be walked, and
+// the numbers JSON cannot encode to strings.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case nil, string, bool, []byte, time.Time, time.Duration:
+		return value
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Sprint(value)
+		}
+		return value
+	case complex64, complex128:
+		return fmt.Sprint(value)
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[key] = normalize(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(value))
+		for i, item := range value {
+			normalized[i] = normalize(item)
+		}
+		return normalized
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+	default:
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(data)
+	}
+	return decoded
+}
+
+// valueField creates a field from a walked value.
+func valueField(key string, value interface{}) zapcore.Field {
+	switch value := value.(type) {
+	case string:
+		return zap.String(key, value)
+	case map[string]interface{}:
+		return zap.Object(key, redactedObject(value))
+	case []interface{}:
+		return zap.Array(key, redactedArray(value))
+	}
+	return zap.Any(key, value)
+}
+
+// redactedObject encodes a walked object.
+type redactedObject map[string]interface{}
+
+func (ro redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(ro))
+	for key := range ro {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+		switch value := ro[key].(type) {
+		case string:
+			enc.AddString(key, value)
+		case bool:
+			enc.AddBool(key, value)
+		case time.Time:
+			enc.AddTime(key, value)
+		case time.Duration:
+			enc.AddDuration(key, value)
+		case map[string]interface{}:
+			err = enc.AddObject(key, redactedObject(value))
+		case []interface{}:
+			err = enc.AddArray(key, redactedArray(value))
+		default:
+			err = enc.AddReflected(key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactedArray encodes a walked array.
+type redactedArray []interface{}
+
+func (ra redactedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, item := range ra {
+		var err error
+		switch value := item.(type) {
+		case string:
+			enc.AppendString(value)
+		case bool:
+			enc.AppendBool(value)
+		case time.Time:
+			enc.AppendTime(value)
+		case time.Duration:
+			enc.AppendDuration(value)
+		case map[string]interface{}:
+			err = enc.AppendObject(redactedObject(value))
+		case []interface{}:
+			err = enc.AppendArray(redactedArray(value))
+		default:
+			err = enc.AppendReflected(value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// luhnValid checks the Luhn checksum of a card number, ignoring spaces and dashes.
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] == ' ' || number[i] == '-' {
+			continue
+		}
+		digit := int(number[i] - '0')
+		if digits%2 == 1 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// redactCore masks the fields and messages of a core.
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (rc *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: rc.Core.With(rc.redactor.Redact(fields)), redactor: rc.redactor}
+}
+
+func (rc *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checkRewrite(rc, rc.Core, entry, checked)
+}
+
+func (rc *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return rc.Core.Write(rc.rewrite(entry, fields))
+}
+
+func (rc *redactCore) rewrite(entry zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	entry.Message = rc.redactor.RedactString(entry.Message)
+	return entry, rc.redactor.Redact(fields)
+}
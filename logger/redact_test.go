@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedactorMask(t *testing.T) {
+	r := NewRedactor(
+		RedactRule{Key: "account", Style: MaskPartial},
+		RedactRule{Key: "ssn", Style: MaskHash},
+		RedactRule{ValuePattern: JWTPattern, Style: MaskFull},
+		RedactRule{ValuePattern: CardNumberPattern, Validate: luhnValid, Style: MaskPartial},
+		RedactRule{ValuePattern: EmailPattern, Style: MaskPartial},
+	)
+	tests := []struct {
+		name  string
+		field zapcore.Field
+		want  string
+	}{
+		{"email", zap.String("user", "jane.doe@example.com"), "j***@example.com"},
+		{"email in text", zap.String("note", "contact jane@example.com today"), "contact j***@example.com today"},
+		{"card number", zap.String("card", "4111 1111 1111 1111"), "***************1111"},
+		{"invalid card number", zap.String("card", "4111 1111 1111 1112"), "4111 1111 1111 1112"},
+		{"JWT", zap.String("header", "Bearer eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig"), "Bearer [REDACTED]"},
+		{"partial key with @", zap.String("account", "admin@internal"), redactedText},
+		{"partial key with an email", zap.String("account", "jane@example.com"), redactedText},
+		{"partial key", zap.String("account", "12345678"), redactedText},
+		{"hashed key", zap.String("ssn", "123-45-6789"), "sha256:"},
+		{"clean", zap.String("path", "/orders@v2"), "/orders@v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := r.Redact([]zapcore.Field{tt.field})[0]
+			if !strings.HasPrefix(redacted.String, tt.want) || (tt.want != "sha256:" && redacted.String != tt.want) {
+				t.Errorf("redacted %q to %q, want %q", tt.field.String, redacted.String, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorNested(t *testing.T) {
+	r := NewRedactor()
+	type request struct {
+		Email    string
+		Password string
+		Tags     []string
+	}
+	fields := []zapcore.Field{
+		zap.Any("request", request{Email: "jane@example.com", Password: "hunter2", Tags: []string{"a", "ops@example.com"}}),
+		zap.Error(errors.New("no user jane@example.com")),
+		zap.Int("count", 3),
+	}
+	redacted := r.Redact(fields)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range redacted {
+		field.AddTo(enc)
+	}
+	object := enc.Fields["request"].(map[string]interface{})
+	if object["Email"] != "j***@example.com" || object["Password"] != redactedText {
+		t.Errorf("unexpected request %v", object)
+	}
+	if tags := object["Tags"].([]interface{}); tags[0] != "a" || tags[1] != "o***@example.com" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if enc.Fields["error"] != "no user j***@example.com" {
+		t.Errorf("unexpected error %v", enc.Fields["error"])
+	}
+	if &redacted[2] == &fields[2] || redacted[2] != fields[2] {
+		t.Error("the fields were not copied, or an unmasked field changed")
+	}
+}
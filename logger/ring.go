@@ -0,0 +1,319 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RingBufferConfig configures a RingBuffer.
+type RingBufferConfig struct {
+	// Size is the number of entries kept, per level if PerLevel is set.
+	// It defaults to 1000.
+	Size int
+	// PerLevel keeps the last Size entries of every level, so that a burst
+	// of debug entries does not push out the errors.
+	PerLevel bool
+	// Level selects the entries kept. It defaults to the level of the Logger.
+	Level zapcore.LevelEnabler
+}
+
+// RecentEntry is an entry kept by a RingBuffer.
+type RecentEntry struct {
+	Seq     uint64                 `json:"seq"`
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Caller  string                 `json:"caller,omitempty"`
+	Message string                 `json:"message"`
+	TraceID string                 `json:"trace_id,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+
+	level zapcore.Level
+}
+
+// RecentFilter selects the entries returned by a RingBuffer.
+// Zero fields match every entry.
+type RecentFilter struct {
+	// MinLevel is the lowest level returned.
+	MinLevel *zapcore.Level
+	Since    time.Time
+	Until    time.Time
+	// Contains is a substring of the message.
+	Contains string
+	TraceID  string
+	// Limit returns only the most recent entries.
+	Limit int
+}
+
+// RingBuffer keeps the most recent log entries in memory, to inspect a live
+// process when the log pipeline is not at hand.
+type RingBuffer struct {
+	cfg RingBufferConfig
+
+	mu    sync.Mutex
+	seq   uint64
+	rings map[zapcore.Level]*entryRing
+}
+
+// entryRing is a fixed size ring of entries.
+type entryRing struct {
+	entries []RecentEntry
+	head    int
+	count   int
+}
+
+// NewRingBuffer creates a RingBuffer. Pass it to New with WithRingBuffer.
+func NewRingBuffer(cfg RingBufferConfig) *RingBuffer {
+	if cfg.Size <= 0 {
+		cfg.Size = 1000
+	}
+	return &RingBuffer{cfg: cfg, rings: map[zapcore.Level]*entryRing{}}
+}
+
+// WithRingBuffer keeps the entries of the Logger in the RingBuffer, after
+// redaction and schema mapping, as they are written to the outputs.
+func WithRingBuffer(rb *RingBuffer) Option {
+	return func(o *options) {
+		o.tees = append(o.tees, func(_ zap.Config, level zapcore.LevelEnabler) zapcore.Core {
+			if rb.cfg.Level != nil {
+				level = rb.cfg.Level
+			}
+			return &ringCore{LevelEnabler: level, buffer: rb}
+		})
+	}
+}
+
+// add keeps an entry, replacing the oldest one of its ring when it is full.
+func (rb *RingBuffer) add(entry RecentEntry) {
+	key := zapcore.InvalidLevel
+	if rb.cfg.PerLevel {
+		key = entry.level
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.seq++
+	entry.Seq = rb.seq
+
+	ring, exists := rb.rings[key]
+	if !exists {
+		ring = &entryRing{entries: make([]RecentEntry, rb.cfg.Size)}
+		rb.rings[key] = ring
+	}
+	if ring.count < len(ring.entries) {
+		ring.entries[(ring.head+ring.count)%len(ring.entries)] = entry
+		ring.count++
+		return
+	}
+	ring.entries[ring.head] = entry
+	ring.head = (ring.head + 1) % len(ring.entries)
+}
+
+// Entries returns the kept entries matching the filter, oldest first.
+func (rb *RingBuffer) Entries(filter RecentFilter) []RecentEntry {
+	rb.mu.Lock()
+	var entries []RecentEntry
+	for _, ring := range rb.rings {
+		for i := 0; i < ring.count; i++ {
+			if entry := ring.entries[(ring.head+i)%len(ring.entries)]; filter.matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	rb.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Seq < entries[j].Seq
+	})
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries
+}
+
+// matches returns true if the entry passes the filter.
+func (f RecentFilter) matches(entry RecentEntry) bool {
+	switch {
+	case f.MinLevel != nil && entry.level < *f.MinLevel:
+		return false
+	case !f.Since.IsZero() && entry.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && entry.Time.After(f.Until):
+		return false
+	case f.Contains != "" && !strings.Contains(entry.Message, f.Contains):
+		return false
+	case f.TraceID != "" && entry.TraceID != f.TraceID && !strings.HasSuffix(entry.TraceID, "/"+f.TraceID):
+		return false
+	}
+	return true
+}
+
+// ServeHTTP serves the kept entries, as JSON or as text lines with format=text
+// or an Accept header preferring text/plain. The query parameters level,
+// since and until (RFC 3339 times or durations before now), q (a message
+// substring), trace_id and limit filter the entries.
+func (rb *RingBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	filter, err := parseRecentFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries := rb.Entries(filter)
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		format = "text"
+	}
+	if format != "text" {
+		w.Header().Set("Content-Type", "application/json")
+		if entries == nil {
+			entries = []RecentEntry{}
+		}
+		json.NewEncoder(w).Encode(entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, entry := range entries {
+		line := []string{entry.Time.Format(time.RFC3339Nano), strings.ToUpper(entry.Level)}
+		for _, part := range []string{entry.Logger, entry.Caller} {
+			if part != "" {
+				line = append(line, part)
+			}
+		}
+		line = append(line, entry.Message)
+		if len(entry.Fields) > 0 {
+			fields, _ := json.Marshal(entry.Fields)
+			line = append(line, string(fields))
+		}
+		fmt.Fprintln(w, strings.Join(line, "\t"))
+		if entry.Stack != "" {
+			fmt.Fprintln(w, entry.Stack)
+		}
+	}
+}
+
+// parseRecentFilter reads a RecentFilter from the query parameters.
+func parseRecentFilter(r *http.Request) (RecentFilter, error) {
+	query := r.URL.Query()
+	filter := RecentFilter{
+		Contains: query.Get("q"),
+		TraceID:  query.Get("trace_id"),
+	}
+	if value := query.Get("level"); value != "" {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return filter, fmt.Errorf("level: %w", err)
+		}
+		filter.MinLevel = &level
+	}
+	var err error
+	if filter.Since, err = parseQueryTime(query.Get("since")); err != nil {
+		return filter, fmt.Errorf("since: %w", err)
+	}
+	if filter.Until, err = parseQueryTime(query.Get("until")); err != nil {
+		return filter, fmt.Errorf("until: %w", err)
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			return filter, fmt.Errorf("limit: invalid number %q", value)
+		}
+	}
+	return filter, nil
+}
+
+// parseQueryTime parses an RFC 3339 time, or a duration before now such as "15m".
+func parseQueryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// traceIDKeys are the keys of the trace ID in the supported schemas.
+var traceIDKeys = []string{"trace.id", "dd.trace_id", "logging.googleapis.com/trace"}
+
+// traceIDOf finds the trace ID among the encoded fields of an entry.
+func traceIDOf(fields map[string]interface{}) string {
+	if trace, ok := fields[TraceFieldKey].(map[string]interface{}); ok {
+		if id, ok := trace["trace_id"].(string); ok {
+			return id
+		}
+	}
+	for _, key := range traceIDKeys {
+		if id, ok := fields[key]; ok {
+			return fmt.Sprint(id)
+		}
+	}
+	return ""
+}
+
+// ringCore keeps the entries of a Logger in a RingBuffer.
+type ringCore struct {
+	zapcore.LevelEnabler
+	buffer *RingBuffer
+	fields []zapcore.Field
+}
+
+func (rc *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(rc.fields)+len(fields))
+	combined = append(combined, rc.fields...)
+	return &ringCore{LevelEnabler: rc.LevelEnabler, buffer: rc.buffer, fields: append(combined, fields...)}
+}
+
+func (rc *ringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(entry.Level) {
+		return checked.AddCore(entry, rc)
+	}
+	return checked
+}
+
+func (rc *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range rc.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	recent := RecentEntry{
+		Time:    entry.Time,
+		Level:   LevelName(entry.Level),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		TraceID: traceIDOf(enc.Fields),
+		Stack:   entry.Stack,
+		level:   entry.Level,
+	}
+	if entry.Caller.Defined {
+		recent.Caller = entry.Caller.TrimmedPath()
+	}
+	if len(enc.Fields) > 0 {
+		recent.Fields = normalize(enc.Fields).(map[string]interface{})
+	}
+	rc.buffer.add(recent)
+	return nil
+}
+
+func (rc *ringCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,470 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// backupTimeFormat is the timestamp added to the names of rotated files.
+const backupTimeFormat = "20060102T150405.000"
+
+// compressSuffix is the extension added to compressed rotated files.
+const compressSuffix = ".gz"
+
+// RotateConfig configures a RotatingFile.
+type RotateConfig struct {
+	// Filename is the file written to. Rotated files are kept next to it.
+	Filename string
+	// MaxSize rotates the file before it grows beyond this many bytes. Zero disables it.
+	MaxSize int64
+	// Interval rotates the file at every multiple of the interval, e.g. 24h
+	// rotates at midnight UTC. Zero disables it.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept. Zero keeps all of them.
+	MaxBackups int
+	// MaxAge removes rotated files older than this. Zero keeps all of them.
+	MaxAge time.Duration
+	// Compress gzips rotated files in the background.
+	Compress bool
+}
+
+// RotatingFile is a zap.Sink writing to a file that is rotated by size and time.
+// Errors of the background compression and cleanup are returned by the next
+// Write or Sync, so zap reports them through the ErrorOutputPaths.
+type RotatingFile struct {
+	cfg RotateConfig
+
+	mu           sync.Mutex
+	file         *os.File
+	closed       bool
+	size         int64
+	nextRotation time.Time
+
+	// backups signals the background work that a file was rotated.
+	backups chan struct{}
+	done    chan struct{}
+	errMu   sync.Mutex
+	errs    []error
+}
+
+// openFiles holds the open rotating files, so they can be reopened on SIGHUP.
+var openFiles = struct {
+	sync.Mutex
+	files map[*RotatingFile]struct{}
+}{files: map[*RotatingFile]struct{}{}}
+
+func init() {
+	if err := zap.RegisterSink("rotate", newRotateSink); err != nil {
+		panic(fmt.Sprintf("Failed to register rotate sink: %v", err))
+	}
+}
+
+// NewRotatingFile opens a RotatingFile, appending to the file if it exists.
+func NewRotatingFile(cfg RotateConfig) (*RotatingFile, error) {
+	if cfg.Filename == "" {
+		return nil, errors.New("rotating file requires a file name")
+	}
+
+	rf := &RotatingFile{
+		cfg:     cfg,
+		backups: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	go rf.processBackups()
+
+	openFiles.Lock()
+	openFiles.files[rf] = struct{}{}
+	openFiles.Unlock()
+	return rf, nil
+}
+
+// newRotateSink creates a RotatingFile from a URL such as
+// rotate:///var/log/app.log?max_size=100MB&interval=24h&max_backups=7&max_age=30d&compress=true
+func newRotateSink(u *url.URL) (zap.Sink, error) {
+	cfg := RotateConfig{Filename: u.Path}
+	if u.Opaque != "" {
+		cfg.Filename = u.Opaque
+	}
+
+	query := u.Query()
+	var err error
+	if value := query.Get("max_size"); value != "" {
+		if cfg.MaxSize, err = parseSize(value); err != nil {
+			return nil, fmt.Errorf("max_size: %w", err)
+		}
+	}
+	if value := query.Get("interval"); value != "" {
+		if cfg.Interval, err = parseAge(value); err != nil {
+			return nil, fmt.Errorf("interval: %w", err)
+		}
+	}
+	if value := query.Get("max_backups"); value != "" {
+		if cfg.MaxBackups, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("max_backups: %w", err)
+		}
+	}
+	if value := query.Get("max_age"); value != "" {
+		if cfg.MaxAge, err = parseAge(value); err != nil {
+			return nil, fmt.Errorf("max_age: %w", err)
+		}
+	}
+	if value := query.Get("compress"); value != "" {
+		if cfg.Compress, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("compress: %w", err)
+		}
+	}
+	return NewRotatingFile(cfg)
+}
+
+// open opens the file for appending. It must be called with the lock held.
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.cfg.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(rf.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	if rf.cfg.Interval > 0 {
+		rf.nextRotation = time.Now().Truncate(rf.cfg.Interval).Add(rf.cfg.Interval)
+	}
+	return nil
+}
+
+// Write writes to the file, rotating it first when needed.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	// A failed rotation or reopen leaves no file open, so try again.
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, rf.takeErrors()
+}
+
+// shouldRotate returns true if writing n bytes requires a rotation first.
+func (rf *RotatingFile) shouldRotate(n int64) bool {
+	if rf.cfg.MaxSize > 0 && rf.size > 0 && rf.size+n > rf.cfg.MaxSize {
+		return true
+	}
+	return rf.cfg.Interval > 0 && !time.Now().Before(rf.nextRotation)
+}
+
+// rotate renames the file to a backup and opens a new one.
+// It must be called with the lock held.
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+
+	if err := os.Rename(rf.cfg.Filename, rf.backupName()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	// A pending signal already covers this backup, so Write never waits for
+	// the background work.
+	select {
+	case rf.backups <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns an unused name for a rotated file, adding a sequence
+// number to the timestamp when a file was rotated in the same millisecond.
+func (rf *RotatingFile) backupName() string {
+	ext := filepath.Ext(rf.cfg.Filename)
+	stamp := strings.TrimSuffix(rf.cfg.Filename, ext) + "-" + time.Now().UTC().Format(backupTimeFormat)
+	backup := stamp + ext
+	for seq := 1; fileExists(backup) || fileExists(backup+compressSuffix); seq++ {
+		backup = fmt.Sprintf("%s-%d%s", stamp, seq, ext)
+	}
+	return backup
+}
+
+// fileExists returns true if the path exists.
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Reopen closes and reopens the file, e.g. after an external logrotate moved it.
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.file != nil {
+		if err := rf.file.Close(); err != nil {
+			rf.addError(err)
+		}
+		rf.file = nil
+	}
+	return rf.open()
+}
+
+// reopenFiles reopens every open RotatingFile.
+// Errors are returned by the next Write or Sync of the file.
+func reopenFiles() {
+	openFiles.Lock()
+	files := make([]*RotatingFile, 0, len(openFiles.files))
+	for rf := range openFiles.files {
+		files = append(files, rf)
+	}
+	openFiles.Unlock()
+
+	for _, rf := range files {
+		if err := rf.Reopen(); err != nil {
+			rf.addError(fmt.Errorf("reopening %s: %w", rf.cfg.Filename, err))
+		}
+	}
+}
+
+// Sync commits the file to stable storage.
+func (rf *RotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.file == nil {
+		return rf.takeErrors()
+	}
+	if err := rf.file.Sync(); err != nil {
+		return err
+	}
+	return rf.takeErrors()
+}
+
+// Close waits for the background compression and cleanup, then closes the file.
+func (rf *RotatingFile) Close() error {
+	openFiles.Lock()
+	delete(openFiles.files, rf)
+	openFiles.Unlock()
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	rf.closed = true
+	close(rf.backups)
+	<-rf.done
+
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	return errors.Join(err, rf.takeErrors())
+}
+
+// processBackups compresses rotated files and removes the expired ones.
+// Every signal processes all rotated files, so signals may be coalesced.
+func (rf *RotatingFile) processBackups() {
+	defer close(rf.done)
+	for range rf.backups {
+		backups, err := rf.listBackups()
+		if err != nil {
+			rf.addError(fmt.Errorf("listing rotated files: %w", err))
+			continue
+		}
+		if rf.cfg.Compress {
+			for i, backup := range backups {
+				if strings.HasSuffix(backup.path, compressSuffix) {
+					continue
+				}
+				if err := compressFile(backup.path); err != nil {
+					rf.addError(fmt.Errorf("compressing %s: %w", backup.path, err))
+					continue
+				}
+				backups[i].path += compressSuffix
+			}
+		}
+		if err := rf.removeExpired(backups); err != nil {
+			rf.addError(fmt.Errorf("removing rotated files: %w", err))
+		}
+	}
+}
+
+// backupFile is a rotated file.
+type backupFile struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// listBackups returns the rotated files, newest first.
+func (rf *RotatingFile) listBackups() ([]backupFile, error) {
+	ext := filepath.Ext(rf.cfg.Filename)
+	prefix := strings.TrimSuffix(rf.cfg.Filename, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, match := range matches {
+		name, found := strings.CutSuffix(strings.TrimSuffix(match, compressSuffix), ext)
+		if !found && ext != "" {
+			continue
+		}
+		stamp, seqText, hasSeq := strings.Cut(strings.TrimPrefix(name, prefix), "-")
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		var seq int
+		if hasSeq {
+			if seq, err = strconv.Atoi(seqText); err != nil {
+				continue
+			}
+		}
+		backups = append(backups, backupFile{path: match, time: t, seq: seq})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// removeExpired removes rotated files beyond MaxBackups or older than MaxAge.
+func (rf *RotatingFile) removeExpired(backups []backupFile) error {
+	if rf.cfg.MaxBackups <= 0 && rf.cfg.MaxAge <= 0 {
+		return nil
+	}
+
+	var errs []error
+	cutoff := time.Now().Add(-rf.cfg.MaxAge)
+	for i, backup := range backups {
+		expired := rf.cfg.MaxBackups > 0 && i >= rf.cfg.MaxBackups
+		expired = expired || rf.cfg.MaxAge > 0 && backup.time.Before(cutoff)
+		if expired {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addError records an error of the background work.
+func (rf *RotatingFile) addError(err error) {
+	rf.errMu.Lock()
+	defer rf.errMu.Unlock()
+	rf.errs = append(rf.errs, err)
+}
+
+// takeErrors returns and clears the recorded errors.
+func (rf *RotatingFile) takeErrors() error {
+	rf.errMu.Lock()
+	defer rf.errMu.Unlock()
+	err := errors.Join(rf.errs...)
+	rf.errs = nil
+	return err
+}
+
+// compressFile gzips the file and removes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	err = errors.Join(err, gz.Close(), dst.Close())
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// parseSize parses a size in bytes with an optional KB, MB or GB suffix.
+func parseSize(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	for _, unit := range units {
+		if number, found := strings.CutSuffix(upper, unit.suffix); found {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			return n * unit.multiplier, err
+		}
+	}
+	return strconv.ParseInt(upper, 10, 64)
+}
+
+// parseAge parses a duration, also accepting a number of days such as "7d".
+func parseAge(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(value)
+}
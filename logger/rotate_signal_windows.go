@@ -0,0 +1,8 @@
+//go:build windows
+
+package logger
+
+// ReopenFilesOnSignal does nothing on Windows, which has no SIGHUP.
+func ReopenFilesOnSignal() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig configures the sampling and rate limiting of WithSampling.
+type SamplingConfig struct {
+	// Tick is the window of the First and Thereafter counts. It defaults to a second.
+	Tick time.Duration
+	// First entries with the same level and message are logged per tick.
+	First int
+	// Thereafter every Mth entry with the same level and message is logged.
+	// Zero disables this sampling, and with it First.
+	Thereafter int
+
+	// Rate is the number of entries per second each call site may log.
+	// Zero disables the rate limiting.
+	Rate float64
+	// Burst is the number of entries a call site may log at once. It defaults to one.
+	Burst int
+
+	// ReportInterval is how often the counts of suppressed entries are logged,
+	// while entries are being suppressed. Sync also logs them. Zero disables
+	// the report.
+	ReportInterval time.Duration
+	// ExemptErrors never suppresses entries at Error level and above.
+	ExemptErrors bool
+}
+
+// WithSampling suppresses repeated entries with zap-style first-N-then-every-M
+// sampling per level and message, and with a token bucket per call site.
+func WithSampling(cfg SamplingConfig) Option {
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+	return WithZapOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newSamplingCore(core, cfg)
+	}))
+}
+
+// suppressedKey identifies the suppressed entries counted together.
+type suppressedKey struct {
+	level   zapcore.Level
+	message string
+	caller  string
+}
+
+// tokenBucket limits the rate of a call site.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// samplingState is shared by a samplingCore and the cores created with With.
+type samplingState struct {
+	cfg SamplingConfig
+	// core is the wrapped core without the fields of With, the report is written to.
+	core zapcore.Core
+
+	mu         sync.Mutex
+	buckets    map[string]*tokenBucket
+	suppressed map[suppressedKey]int
+	lastReport time.Time
+	// reporting is true while the report ticker runs.
+	reporting bool
+}
+
+// samplingCore suppresses entries of a core by sampling and rate limiting.
+type samplingCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+	state   *samplingState
+}
+
+// newSamplingCore creates a samplingCore around the core.
+func newSamplingCore(core zapcore.Core, cfg SamplingConfig) *samplingCore {
+	sc := &samplingCore{
+		Core: core,
+		state: &samplingState{
+			cfg:        cfg,
+			core:       core,
+			buckets:    map[string]*tokenBucket{},
+			suppressed: map[suppressedKey]int{},
+			lastReport: time.Now(),
+		},
+	}
+	sc.sampled = core
+	if cfg.Thereafter > 0 {
+		sc.sampled = zapcore.NewSamplerWithOptions(
+			core, cfg.Tick, cfg.First, cfg.Thereafter,
+			zapcore.SamplerHook(func(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+				if decision&zapcore.LogDropped != 0 {
+					sc.state.suppress(entry)
+				}
+			}),
+		)
+	}
+	return sc
+}
+
+func (sc *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{
+		Core:    sc.Core.With(fields),
+		sampled: sc.sampled.With(fields),
+		state:   sc.state,
+	}
+}
+
+func (sc *samplingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !sc.Enabled(entry.Level) {
+		return checked
+	}
+
+	cfg := sc.state.cfg
+	if cfg.ExemptErrors && entry.Level >= zapcore.ErrorLevel {
+		return sc.Core.Check(entry, checked)
+	}
+	if cfg.Rate <= 0 {
+		return sc.sampled.Check(entry, checked)
+	}
+
+	// The call site is only known in Write, so the entry is sampled now and
+	// written through the inner checked entry only if the call site has a token.
+	inner := sc.sampled.Check(entry, nil)
+	if inner == nil {
+		return checked
+	}
+	re := &rateLimitedEntry{samplingCore: sc, inner: inner}
+	checked = checked.AddCore(entry, re)
+	re.outer = checked
+	return checked
+}
+
+func (sc *samplingCore) Sync() error {
+	sc.state.report(time.Now())
+	return sc.Core.Sync()
+}
+
+// rateLimitedEntry writes an entry checked by a samplingCore if its call site
+// is within the rate.
+type rateLimitedEntry struct {
+	*samplingCore
+	inner *zapcore.CheckedEntry
+	outer *zapcore.CheckedEntry
+}
+
+func (re *rateLimitedEntry) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !re.state.allow(entry) {
+		re.state.suppress(entry)
+		return nil
+	}
+	// The caller and stack are added to the outer entry after Check.
+	re.inner.Entry = entry
+	re.inner.ErrorOutput = re.outer.ErrorOutput
+	re.inner.Write(fields...)
+	return nil
+}
+
+// allow takes a token from the bucket of the entry's call site, or of its
+// message when the caller is disabled.
+func (ss *samplingState) allow(entry zapcore.Entry) bool {
+	site := entry.Message
+	if entry.Caller.Defined {
+		site = entry.Caller.String()
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	bucket, exists := ss.buckets[site]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(ss.cfg.Burst), last: entry.Time}
+		ss.buckets[site] = bucket
+	}
+
+	elapsed := entry.Time.Sub(bucket.last).Seconds()
+	if elapsed > 0 {
+		bucket.tokens += elapsed * ss.cfg.Rate
+		if bucket.tokens > float64(ss.cfg.Burst) {
+			bucket.tokens = float64(ss.cfg.Burst)
+		}
+		bucket.last = entry.Time
+	}
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// suppress counts a suppressed entry for the next report, and starts the
+// report ticker. Entries dropped by the sampling have no caller yet, and are
+// counted per message.
+func (ss *samplingState) suppress(entry zapcore.Entry) {
+	if ss.cfg.ReportInterval <= 0 {
+		return
+	}
+	key := suppressedKey{level: entry.Level, message: entry.Message}
+	if entry.Caller.Defined {
+		key.caller = entry.Caller.TrimmedPath()
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.suppressed[key]++
+	if !ss.reporting {
+		ss.reporting = true
+		go ss.reportEvery(ss.cfg.ReportInterval)
+	}
+}
+
+// reportEvery writes the report every interval, until an interval passes
+// without suppressed entries.
+func (ss *samplingState) reportEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		ss.mu.Lock()
+		idle := len(ss.suppressed) == 0
+		if idle {
+			ss.reporting = false
+		}
+		ss.mu.Unlock()
+		if idle {
+			return
+		}
+		ss.report(now)
+	}
+}
+
+// report writes the counts of the entries suppressed since the last report.
+func (ss *samplingState) report(now time.Time) {
+	ss.mu.Lock()
+	suppressed := ss.suppressed
+	since := ss.lastReport
+	ss.suppressed = map[suppressedKey]int{}
+	ss.lastReport = now
+	ss.mu.Unlock()
+
+	if len(suppressed) == 0 {
+		return
+	}
+	entry := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    now,
+		Message: "log entries suppressed",
+	}
+	checked := ss.core.Check(entry, nil)
+	if checked == nil {
+		return
+	}
+
+	counts := make(suppressedCounts, 0, len(suppressed))
+	total := 0
+	for key, count := range suppressed {
+		counts = append(counts, suppressedCount{suppressedKey: key, count: count})
+		total += count
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].count > counts[j].count
+	})
+	checked.Write(
+		zap.Int("suppressed", total),
+		zap.Duration("period", now.Sub(since)),
+		zap.Array("entries", counts),
+	)
+}
+
+// suppressedCount is the number of suppressed entries of a key.
+type suppressedCount struct {
+	suppressedKey
+	count int
+}
+
+func (sc suppressedCount) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("level", LevelName(sc.level))
+	enc.AddString("message", sc.message)
+	if sc.caller != "" {
+		enc.AddString("caller", sc.caller)
+	}
+	enc.AddInt("count", sc.count)
+	return nil
+}
+
+// suppressedCounts encodes the counts as an array.
+type suppressedCounts []suppressedCount
+
+func (scs suppressedCounts) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, count := range scs {
+		if err := enc.AppendObject(count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
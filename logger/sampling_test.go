@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newSampledLogger creates a zap logger sampling into an observer core at the level.
+func newSampledLogger(cfg SamplingConfig, level zapcore.Level) (*zap.Logger, *samplingCore, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+	sc := newSamplingCore(core, cfg)
+	return zap.New(sc, zap.AddCaller()), sc, logs
+}
+
+// waitForMessage waits until an entry with the message is logged.
+func waitForMessage(t *testing.T, logs *observer.ObservedLogs, message string) []observer.LoggedEntry {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if entries := logs.FilterMessage(message).All(); len(entries) > 0 {
+			return entries
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no %q entry logged", message)
+	return nil
+}
+
+func TestSamplingFirstThereafter(t *testing.T) {
+	l, _, logs := newSampledLogger(SamplingConfig{First: 2, Thereafter: 3}, zapcore.DebugLevel)
+	for i := 0; i < 8; i++ {
+		l.Info("repeated")
+	}
+	l.Info("other")
+	// The first 2, then every 3rd: entries 1, 2, 5 and 8.
+	if count := logs.FilterMessage("repeated").Len(); count != 4 {
+		t.Errorf("logged %d repeated entries, want 4", count)
+	}
+	if logs.FilterMessage("other").Len() != 1 {
+		t.Error("entry with another message suppressed")
+	}
+}
+
+func TestSamplingRatePerCallSite(t *testing.T) {
+	l, _, logs := newSampledLogger(SamplingConfig{Rate: 0.001, Burst: 2, ExemptErrors: true}, zapcore.DebugLevel)
+	for i := 0; i < 5; i++ {
+		l.Info("first site")
+		l.Info("second site")
+		l.Error("failed")
+	}
+	for message, want := range map[string]int{"first site": 2, "second site": 2, "failed": 5} {
+		if count := logs.FilterMessage(message).Len(); count != want {
+			t.Errorf("logged %d %q entries, want %d", count, message, want)
+		}
+	}
+}
+
+func TestSamplingReportsPeriodically(t *testing.T) {
+	l, sc, logs := newSampledLogger(SamplingConfig{
+		First:          1,
+		Thereafter:     100,
+		ReportInterval: 20 * time.Millisecond,
+	}, zapcore.DebugLevel)
+	l = l.With(zap.String("request_id", "r-1"))
+	for i := 0; i < 4; i++ {
+		l.Debug("repeated")
+	}
+
+	// No log call or Sync follows: the report is written by the ticker.
+	reports := waitForMessage(t, logs, "log entries suppressed")
+	report := reports[0]
+	if report.Level != zapcore.WarnLevel {
+		t.Errorf("report at level %v", report.Level)
+	}
+	fields := report.ContextMap()
+	if fields["suppressed"] != int64(3) {
+		t.Errorf("report counts %v suppressed entries, want 3", fields["suppressed"])
+	}
+	if _, exists := fields["request_id"]; exists {
+		t.Error("report has the fields of With")
+	}
+	entries, _ := fields["entries"].([]interface{})
+	if len(entries) != 1 {
+		t.Fatalf("report entries %v", fields["entries"])
+	}
+	if entry := entries[0].(map[string]interface{}); entry["message"] != "repeated" || entry["level"] != "debug" || entry["count"] != 3 {
+		t.Errorf("unexpected report entry %v", entry)
+	}
+
+	// The ticker stops once nothing is suppressed.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sc.state.mu.Lock()
+		reporting := sc.state.reporting
+		sc.state.mu.Unlock()
+		if !reporting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("report ticker still running")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if count := logs.FilterMessage("log entries suppressed").Len(); count != 1 {
+		t.Errorf("got %d reports, want 1", count)
+	}
+}
+
+func TestSamplingReportChecksTheCore(t *testing.T) {
+	l, _, logs := newSampledLogger(SamplingConfig{
+		First:          1,
+		Thereafter:     100,
+		ReportInterval: time.Hour,
+	}, zapcore.ErrorLevel)
+	for i := 0; i < 3; i++ {
+		l.Error("failed")
+	}
+	l.Sync()
+	if count := logs.FilterMessage("log entries suppressed").Len(); count != 0 {
+		t.Errorf("report written to a core at Error level")
+	}
+}
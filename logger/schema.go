@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TraceFieldKey is the key of the field the trace-aware logger adds to every entry.
+const TraceFieldKey = "Trace"
+
+// Names of the schemas returned by SchemaByName.
+const (
+	SchemaNameECS         = "ecs"
+	SchemaNameGoogleCloud = "gcp"
+	SchemaNameDatadog     = "datadog"
+)
+
+// Schema maps the standard keys and the Trace field to the field names of a log platform.
+type Schema struct {
+	// Name identifies the schema.
+	Name string
+	// Encoder adjusts the keys and encoders of the encoder config.
+	Encoder func(cfg *zapcore.EncoderConfig)
+	// Trace returns the fields replacing the Trace field.
+	Trace func(traceID, spanID string) []zapcore.Field
+}
+
+// SchemaECS is the Elastic Common Schema.
+var SchemaECS = Schema{
+	Name: SchemaNameECS,
+	Encoder: func(cfg *zapcore.EncoderConfig) {
+		cfg.TimeKey = "@timestamp"
+		cfg.LevelKey = "log.level"
+		cfg.NameKey = "log.logger"
+		cfg.CallerKey = "log.origin.file.name"
+		cfg.MessageKey = "message"
+		cfg.StacktraceKey = "error.stack_trace"
+		cfg.EncodeLevel = LowercaseLevelEncoder
+		cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	},
+	Trace: func(traceID, spanID string) []zapcore.Field {
+		return []zapcore.Field{
+			zap.String("trace.id", traceID),
+			zap.String("span.id", spanID),
+		}
+	},
+}
+
+// SchemaDatadog is the Datadog log schema.
+// Trace and span IDs are converted to the unsigned 64-bit decimals Datadog
+// correlates with APM traces, using the lower 64 bits of the trace ID.
+var SchemaDatadog = Schema{
+	Name: SchemaNameDatadog,
+	Encoder: func(cfg *zapcore.EncoderConfig) {
+		cfg.TimeKey = "timestamp"
+		cfg.LevelKey = "status"
+		cfg.NameKey = "logger.name"
+		cfg.CallerKey = "caller"
+		cfg.MessageKey = "message"
+		cfg.StacktraceKey = "error.stack"
+		cfg.EncodeLevel = LowercaseLevelEncoder
+		cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	},
+	Trace: func(traceID, spanID string) []zapcore.Field {
+		return []zapcore.Field{
+			zap.String("dd.trace_id", hexToDecimal(traceID)),
+			zap.String("dd.span_id", hexToDecimal(spanID)),
+		}
+	},
+}
+
+// SchemaGoogleCloud is the Google Cloud Logging structured log schema.
+// The project ID is needed to build the full trace resource name.
+func SchemaGoogleCloud(projectID string) Schema {
+	return Schema{
+		Name: SchemaNameGoogleCloud,
+		Encoder: func(cfg *zapcore.EncoderConfig) {
+			cfg.TimeKey = "time"
+			cfg.LevelKey = "severity"
+			cfg.NameKey = "logger"
+			cfg.CallerKey = "caller"
+			cfg.MessageKey = "message"
+			cfg.StacktraceKey = "stack_trace"
+			cfg.EncodeLevel = googleCloudSeverityEncoder
+			cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		},
+		Trace: func(traceID, spanID string) []zapcore.Field {
+			return []zapcore.Field{
+				zap.String("logging.googleapis.com/trace", fmt.Sprintf("projects/%s/traces/%s", projectID, traceID)),
+				zap.String("logging.googleapis.com/spanId", spanID),
+			}
+		},
+	}
+}
+
+// SchemaByName returns a schema by name: ecs, datadog, or gcp:PROJECT_ID.
+func SchemaByName(name string) (Schema, error) {
+	schema, projectID, _ := strings.Cut(name, ":")
+	switch schema {
+	case SchemaNameECS:
+		return SchemaECS, nil
+	case SchemaNameDatadog:
+		return SchemaDatadog, nil
+	case SchemaNameGoogleCloud:
+		if projectID == "" {
+			return Schema{}, fmt.Errorf("schema %q requires a project, e.g. gcp:my-project", name)
+		}
+		return SchemaGoogleCloud(projectID), nil
+	default:
+		return Schema{}, fmt.Errorf("unknown schema %q", name)
+	}
+}
+
+// WithSchema names the fields of the Logger after the schema.
+func WithSchema(schema Schema) Option {
+	return func(o *options) {
+		o.configure = append(o.configure, func(cfg *zap.Config) {
+			schema.Encoder(&cfg.EncoderConfig)
+		})
+		o.rewrite = append(o.rewrite, func(core zapcore.Core) zapcore.Core {
+			return &schemaCore{Core: core, schema: schema}
+		})
+	}
+}
+
+// googleCloudSeverityEncoder encodes levels as Google Cloud Logging severities.
+func googleCloudSeverityEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch {
+	case level < zapcore.InfoLevel:
+		enc.AppendString("DEBUG")
+	case level == zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case level == zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case level == zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case level == zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case level == zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	default:
+		enc.AppendString("EMERGENCY")
+	}
+}
+
+// hexToDecimal converts the lower 64 bits of a hex ID to an unsigned decimal.
+func hexToDecimal(id string) string {
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return id
+	}
+	var n uint64
+	for _, v := range b {
+		n = n<<8 | uint64(v)
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// schemaCore replaces the Trace field with the fields of a schema.
+type schemaCore struct {
+	zapcore.Core
+	schema Schema
+}
+
+func (sc *schemaCore) With(fields []zapcore.Field) zapcore.Core {
+	return &schemaCore{Core: sc.Core.With(sc.mapFields(fields)), schema: sc.schema}
+}
+
+func (sc *schemaCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checkRewrite(sc, sc.Core, entry, checked)
+}
+
+func (sc *schemaCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return sc.Core.Write(sc.rewrite(entry, fields))
+}
+
+func (sc *schemaCore) rewrite(entry zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	return entry, sc.mapFields(fields)
+}
+
+// mapFields returns the fields with the Trace field replaced.
+// The slice is only copied when it contains a Trace field.
+func (sc *schemaCore) mapFields(fields []zapcore.Field) []zapcore.Field {
+	for i, field := range fields {
+		if field.Key != TraceFieldKey || field.Type != zapcore.ObjectMarshalerType {
+			continue
+		}
+
+		trace := zapcore.NewMapObjectEncoder()
+		if err := field.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(trace); err != nil {
+			continue
+		}
+		traceID, _ := trace.Fields["trace_id"].(string)
+		spanID, _ := trace.Fields["span_id"].(string)
+
+		mapped := make([]zapcore.Field, 0, len(fields)+1)
+		mapped = append(mapped, fields[:i]...)
+		mapped = append(mapped, sc.schema.Trace(traceID, spanID)...)
+		return append(mapped, sc.mapFields(fields[i+1:])...)
+	}
+	return fields
+}
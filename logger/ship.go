@@ -0,0 +1,629 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ShipperConfig configures a Shipper.
+type ShipperConfig struct {
+	// URL is the endpoint, or the base URL of Loki and Elasticsearch.
+	URL string
+	// Client sends the requests. It defaults to a client with a 10 second timeout.
+	Client *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+	// Gzip compresses the request bodies.
+	Gzip bool
+
+	// BatchSize is the maximum number of entries per request. It defaults to 500.
+	BatchSize int
+	// BatchBytes is the maximum size of the entries of a request. It defaults to 1 MiB.
+	BatchBytes int
+	// FlushInterval is how often partial batches are sent. It defaults to a second.
+	FlushInterval time.Duration
+	// BufferSize is the maximum number of entries waiting to be sent. Further
+	// entries are spilled to disk if SpillDir is set and dropped otherwise.
+	// It defaults to 10000.
+	BufferSize int
+
+	// MaxRetries is how often a failed request is retried. It defaults to 5,
+	// and a negative value disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the jittered exponential backoff between
+	// retries. They default to 100 milliseconds and 10 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// SpillDir, if set, keeps the entries that could not be buffered or sent
+	// in files, which are sent once the endpoint accepts requests again.
+	// Once a batch fails, the rest of the buffer is spilled without being
+	// sent. Batches the endpoint rejected, e.g. with a 4xx status, are dropped.
+	SpillDir string
+	// SpillMaxBytes bounds the size of the spilled files. It defaults to 100 MiB.
+	SpillMaxBytes int64
+
+	// Level selects the entries shipped. It defaults to the level of the Logger.
+	Level zapcore.LevelEnabler
+}
+
+// ShipperStats are the self-metrics of a Shipper.
+type ShipperStats struct {
+	Buffered int    `json:"buffered"`
+	Sent     uint64 `json:"sent"`
+	Batches  uint64 `json:"batches"`
+	Bytes    uint64 `json:"bytes"`
+	Retries  uint64 `json:"retries"`
+	// Failed counts the entries whose first send failed, Replayed the spilled
+	// entries sent later. Replayed entries are also counted in Sent.
+	Failed    uint64 `json:"failed"`
+	Dropped   uint64 `json:"dropped"`
+	Spilled   uint64 `json:"spilled"`
+	Replayed  uint64 `json:"replayed"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// shippedLine is an encoded entry with the time it was logged.
+type shippedLine struct {
+	time time.Time
+	line []byte
+}
+
+// shipFormat builds the request bodies of an endpoint.
+type shipFormat interface {
+	// path is appended to the configured URL.
+	path() string
+	contentType() string
+	encode(buf *bytes.Buffer, lines []shippedLine) error
+	// check reports failures in the body of a successful response for the
+	// lines sent, with a *partialError if only some lines were rejected.
+	check(body []byte, lines []shippedLine) error
+}
+
+// errNotRetryable marks the request errors that retrying cannot fix.
+var errNotRetryable = errors.New("not retryable")
+
+// partialError reports the entries of a successful request that the endpoint
+// rejected, e.g. the items of an Elasticsearch bulk request.
+type partialError struct {
+	// retry are the entries rejected for now, e.g. with a 429 status.
+	retry []shippedLine
+	// rejected counts the entries that can never be accepted.
+	rejected int
+	// reason is the first rejection.
+	reason string
+}
+
+func (pe *partialError) Error() string {
+	return fmt.Sprintf("%d entries rejected and %d to retry: %s", pe.rejected, len(pe.retry), pe.reason)
+}
+
+// Shipper sends the log entries of a Logger in batches to a log store.
+// It is a zapcore.WriteSyncer receiving one JSON entry per write, attached
+// with WithShipper.
+type Shipper struct {
+	cfg    ShipperConfig
+	url    string
+	format shipFormat
+
+	mu      sync.Mutex
+	pending []shippedLine
+	size    int
+	lastErr error
+	closed  bool
+
+	wake    chan struct{}
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	spillMu sync.Mutex
+
+	sent, batches, bytes, retries, failed, dropped, spilled, replayed atomic.Uint64
+}
+
+// newShipper creates a Shipper for the format and starts sending.
+func newShipper(cfg ShipperConfig, format shipFormat) *Shipper {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BatchBytes <= 0 {
+		cfg.BatchBytes = 1 << 20
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.SpillMaxBytes <= 0 {
+		cfg.SpillMaxBytes = 100 << 20
+	}
+
+	s := &Shipper{
+		cfg:     cfg,
+		url:     strings.TrimSuffix(cfg.URL, "/") + format.path(),
+		format:  format,
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+	return s
+}
+
+// WithShipper sends the entries of the Logger, encoded as JSON with the
+// encoder config of the Logger, to the Shipper.
+func WithShipper(s *Shipper) Option {
+	return func(o *options) {
+		o.tees = append(o.tees, func(cfg zap.Config, level zapcore.LevelEnabler) zapcore.Core {
+			if s.cfg.Level != nil {
+				level = s.cfg.Level
+			}
+			return zapcore.NewCore(zapcore.NewJSONEncoder(cfg.EncoderConfig), s, level)
+		})
+	}
+}
+
+// Write buffers an encoded entry.
+func (s *Shipper) Write(p []byte) (int, error) {
+	line := shippedLine{time: time.Now(), line: bytes.TrimRight(append([]byte(nil), p...), "\n")}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return len(p), nil
+	}
+	if len(s.pending) >= s.cfg.BufferSize {
+		if s.cfg.SpillDir == "" {
+			s.mu.Unlock()
+			s.dropped.Add(1)
+			return len(p), nil
+		}
+		overflow := s.pending
+		s.pending, s.size = nil, 0
+		s.mu.Unlock()
+		s.spill(overflow)
+		s.mu.Lock()
+	}
+	s.pending = append(s.pending, line)
+	s.size += len(line.line)
+	full := len(s.pending) >= s.cfg.BatchSize || s.size >= s.cfg.BatchBytes
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Sync sends the buffered entries and returns the last send error.
+func (s *Shipper) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case s.flushes <- ack:
+		<-ack
+	case <-s.done:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.lastErr
+	s.lastErr = nil
+	return err
+}
+
+// Close sends the buffered entries until the context is done, spilling the
+// rest to disk if configured, and stops the Shipper. It can be registered
+// with RegisterFlusher.
+func (s *Shipper) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+
+	remaining := s.takeAll()
+	if len(remaining) > 0 {
+		if s.cfg.SpillDir != "" {
+			s.spill(remaining)
+		} else {
+			s.dropped.Add(uint64(len(remaining)))
+		}
+		return fmt.Errorf("log shipper: %d entries not sent: %w", len(remaining), ctx.Err())
+	}
+	return nil
+}
+
+// Stats returns the self-metrics.
+func (s *Shipper) Stats() ShipperStats {
+	s.mu.Lock()
+	stats := ShipperStats{Buffered: len(s.pending)}
+	if s.lastErr != nil {
+		stats.LastError = s.lastErr.Error()
+	}
+	s.mu.Unlock()
+
+	stats.Sent = s.sent.Load()
+	stats.Batches = s.batches.Load()
+	stats.Bytes = s.bytes.Load()
+	stats.Retries = s.retries.Load()
+	stats.Failed = s.failed.Load()
+	stats.Dropped = s.dropped.Load()
+	stats.Spilled = s.spilled.Load()
+	stats.Replayed = s.replayed.Load()
+	return stats
+}
+
+// run sends the batches until the Shipper is closed.
+func (s *Shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.wake:
+			s.flush()
+		case ack := <-s.flushes:
+			s.flush()
+			close(ack)
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush sends the buffered entries, and then the spilled ones if the
+// endpoint accepted them. Once a batch could not be sent, the rest of the
+// buffer is spilled, or kept for the next flush without a spill directory,
+// instead of being retried batch by batch.
+func (s *Shipper) flush() {
+	for s.ctx.Err() == nil {
+		batch := s.takeBatch()
+		if len(batch) == 0 {
+			break
+		}
+		_, unsent, err := s.send(batch)
+		if err == nil {
+			continue
+		}
+		s.failed.Add(uint64(len(unsent)))
+		if errors.Is(err, errNotRetryable) {
+			s.dropped.Add(uint64(len(unsent)))
+			continue
+		}
+		s.spill(unsent)
+		if s.cfg.SpillDir != "" {
+			s.spill(s.takeAll())
+		}
+		return
+	}
+	if s.cfg.SpillDir != "" {
+		s.replay()
+	}
+}
+
+// takeBatch removes the next batch from the buffer.
+func (s *Shipper) takeBatch() []shippedLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count, size := 0, 0
+	for count < len(s.pending) && count < s.cfg.BatchSize {
+		size += len(s.pending[count].line)
+		count++
+		if size >= s.cfg.BatchBytes {
+			break
+		}
+	}
+	batch := s.pending[:count:count]
+	s.pending = s.pending[count:]
+	s.size -= size
+	return batch
+}
+
+// takeAll removes the buffered entries.
+func (s *Shipper) takeAll() []shippedLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch := s.pending
+	s.pending, s.size = nil, 0
+	return batch
+}
+
+// send posts a batch, retrying with backoff, and returns the number of
+// entries the endpoint accepted and the entries not sent because of err.
+// Only the entries rejected for now are retried, and the entries that can
+// never be accepted are dropped.
+func (s *Shipper) send(batch []shippedLine) (sent int, unsent []shippedLine, err error) {
+	backoff := s.cfg.MinBackoff
+	for attempt := 0; ; attempt++ {
+		var body []byte
+		if body, err = s.body(batch); err != nil {
+			err = fmt.Errorf("%w: %v", errNotRetryable, err)
+			break
+		}
+		err = s.post(body, batch)
+		var partial *partialError
+		if err == nil || errors.As(err, &partial) {
+			accepted := len(batch)
+			if partial != nil {
+				accepted -= len(partial.retry) + partial.rejected
+				if partial.rejected > 0 {
+					s.dropped.Add(uint64(partial.rejected))
+					s.fail(err)
+				}
+			}
+			sent += accepted
+			s.sent.Add(uint64(accepted))
+			s.batches.Add(1)
+			s.bytes.Add(uint64(len(body)))
+			if partial == nil || len(partial.retry) == 0 {
+				return sent, nil, nil
+			}
+			batch = partial.retry
+		}
+		if errors.Is(err, errNotRetryable) || attempt >= s.cfg.MaxRetries {
+			break
+		}
+
+		s.retries.Add(1)
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(sleep):
+		case <-s.ctx.Done():
+			s.fail(err)
+			return sent, batch, err
+		}
+		if backoff *= 2; backoff > s.cfg.MaxBackoff {
+			backoff = s.cfg.MaxBackoff
+		}
+	}
+	s.fail(err)
+	return sent, batch, err
+}
+
+// body encodes and optionally compresses a batch.
+func (s *Shipper) body(batch []shippedLine) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.format.encode(&buf, batch); err != nil {
+		return nil, err
+	}
+	if !s.cfg.Gzip {
+		return buf.Bytes(), nil
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// post sends the request body of the lines. Client errors other than 429
+// are not retryable.
+func (s *Shipper) post(body []byte, lines []shippedLine) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNotRetryable, err)
+	}
+	for key, values := range s.cfg.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", s.format.contentType())
+	if s.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		err := s.format.check(respBody, lines)
+		var partial *partialError
+		if err != nil && !errors.As(err, &partial) {
+			return fmt.Errorf("%w: %v", errNotRetryable, err)
+		}
+		return err
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(respBody))
+	default:
+		return fmt.Errorf("%w: %s: %s", errNotRetryable, resp.Status, bytes.TrimSpace(respBody))
+	}
+}
+
+// fail records a send error for Sync and Stats.
+func (s *Shipper) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = fmt.Errorf("log shipper %s: %w", s.url, err)
+}
+
+// spill writes entries to a new file in the spill directory, or drops them
+// when there is no directory or it is full.
+func (s *Shipper) spill(batch []shippedLine) {
+	if len(batch) == 0 {
+		return
+	}
+	if s.cfg.SpillDir == "" {
+		s.dropped.Add(uint64(len(batch)))
+		return
+	}
+
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+	err := os.MkdirAll(s.cfg.SpillDir, 0o755)
+	data := encodeSpill(batch)
+	if err == nil && s.spillSize()+int64(len(data)) > s.cfg.SpillMaxBytes {
+		err = errors.New("spill directory full")
+	}
+	if err == nil {
+		name := filepath.Join(s.cfg.SpillDir, fmt.Sprintf("spill-%d.log", time.Now().UnixNano()))
+		err = os.WriteFile(name, data, 0o644)
+	}
+	if err != nil {
+		s.dropped.Add(uint64(len(batch)))
+		s.mu.Lock()
+		s.lastErr = fmt.Errorf("log shipper spill: %w", err)
+		s.mu.Unlock()
+		return
+	}
+	s.spilled.Add(uint64(len(batch)))
+}
+
+// spillFiles returns the spilled files, oldest first.
+func (s *Shipper) spillFiles() []string {
+	files, _ := filepath.Glob(filepath.Join(s.cfg.SpillDir, "spill-*.log"))
+	sort.Strings(files)
+	return files
+}
+
+// spillSize returns the size of the spilled files.
+func (s *Shipper) spillSize() int64 {
+	var size int64
+	for _, file := range s.spillFiles() {
+		if info, err := os.Stat(file); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// replay sends the spilled files, oldest first, until a send fails.
+// Batches the endpoint rejects are dropped.
+func (s *Shipper) replay() {
+	s.spillMu.Lock()
+	files := s.spillFiles()
+	s.spillMu.Unlock()
+
+	for _, file := range files {
+		batch, err := readSpill(file)
+		if err != nil {
+			continue
+		}
+		for start := 0; start < len(batch); start += s.cfg.BatchSize {
+			end := start + s.cfg.BatchSize
+			if end > len(batch) {
+				end = len(batch)
+			}
+			if s.ctx.Err() != nil {
+				s.rewriteSpill(file, batch[start:])
+				return
+			}
+			sent, unsent, err := s.send(batch[start:end])
+			s.replayed.Add(uint64(sent))
+			if errors.Is(err, errNotRetryable) {
+				s.dropped.Add(uint64(len(unsent)))
+				continue
+			} else if err != nil {
+				// Keep the unsent part of the file for the next attempt.
+				s.rewriteSpill(file, append(append([]shippedLine(nil), unsent...), batch[end:]...))
+				return
+			}
+		}
+		os.Remove(file)
+	}
+}
+
+// rewriteSpill replaces a spilled file with the entries not sent yet.
+func (s *Shipper) rewriteSpill(file string, batch []shippedLine) {
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+	os.WriteFile(file, encodeSpill(batch), 0o644)
+}
+
+// encodeSpill encodes entries as lines of the Unix time in nanoseconds and the entry.
+func encodeSpill(batch []shippedLine) []byte {
+	var buf bytes.Buffer
+	for _, line := range batch {
+		buf.WriteString(strconv.FormatInt(line.time.UnixNano(), 10))
+		buf.WriteByte(' ')
+		buf.Write(line.line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// readSpill reads the entries of a spilled file.
+func readSpill(file string) ([]shippedLine, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var batch []shippedLine
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		stamp, line, found := bytes.Cut(scanner.Bytes(), []byte(" "))
+		nanos, err := strconv.ParseInt(string(stamp), 10, 64)
+		if !found || err != nil {
+			continue
+		}
+		batch = append(batch, shippedLine{time: time.Unix(0, nanos), line: append([]byte(nil), line...)})
+	}
+	return batch, scanner.Err()
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// NewLokiShipper creates a Shipper posting to the push API of the Grafana
+// Loki at the URL, as a single stream with the labels.
+func NewLokiShipper(cfg ShipperConfig, labels map[string]string) *Shipper {
+	return newShipper(cfg, lokiFormat{labels: labels})
+}
+
+// NewElasticsearchShipper creates a Shipper posting to the bulk API of the
+// Elasticsearch at the URL, indexing the entries into the index or data stream.
+func NewElasticsearchShipper(cfg ShipperConfig, index string) *Shipper {
+	return newShipper(cfg, elasticsearchFormat{index: index})
+}
+
+// NewHTTPShipper creates a Shipper posting the entries as JSON lines to the URL.
+func NewHTTPShipper(cfg ShipperConfig) *Shipper {
+	return newShipper(cfg, jsonLinesFormat{})
+}
+
+// lokiFormat builds Loki push requests.
+type lokiFormat struct {
+	labels map[string]string
+}
+
+// lokiPush is the body of a Loki push request.
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (lokiFormat) path() string {
+	return "/loki/api/v1/push"
+}
+
+func (lokiFormat) contentType() string {
+	return "application/json"
+}
+
+func (lf lokiFormat) encode(buf *bytes.Buffer, lines []shippedLine) error {
+	stream := lokiStream{Stream: lf.labels, Values: make([][2]string, len(lines))}
+	if stream.Stream == nil {
+		stream.Stream = map[string]string{}
+	}
+	for i, line := range lines {
+		stream.Values[i] = [2]string{strconv.FormatInt(line.time.UnixNano(), 10), string(line.line)}
+	}
+	return json.NewEncoder(buf).Encode(lokiPush{Streams: []lokiStream{stream}})
+}
+
+func (lokiFormat) check([]byte, []shippedLine) error {
+	return nil
+}
+
+// elasticsearchFormat builds Elasticsearch bulk requests.
+type elasticsearchFormat struct {
+	index string
+}
+
+func (elasticsearchFormat) path() string {
+	return "/_bulk"
+}
+
+func (elasticsearchFormat) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (ef elasticsearchFormat) encode(buf *bytes.Buffer, lines []shippedLine) error {
+	action, err := json.Marshal(map[string]map[string]string{"create": {"_index": ef.index}})
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(line.line)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// check reports the items rejected by a bulk request, which succeeds as a
+// whole. Items rejected with 429 are retried, and the others dropped.
+func (elasticsearchFormat) check(body []byte, lines []shippedLine) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || !result.Errors {
+		return nil
+	}
+	if len(result.Items) != len(lines) {
+		return errors.New("bulk request had errors")
+	}
+
+	// The reason is the first item dropped, or else the first one retried.
+	partial := &partialError{}
+	var retryReason string
+	for i, item := range result.Items {
+		for _, status := range item {
+			if status.Error == nil {
+				continue
+			}
+			reason := "bulk item rejected: " + status.Error.Type + ": " + status.Error.Reason
+			if status.Status == http.StatusTooManyRequests {
+				partial.retry = append(partial.retry, lines[i])
+				if retryReason == "" {
+					retryReason = reason
+				}
+			} else if partial.rejected++; partial.reason == "" {
+				partial.reason = reason
+			}
+		}
+	}
+	if partial.reason == "" {
+		partial.reason = retryReason
+	}
+	if partial.reason == "" {
+		return errors.New("bulk request had errors")
+	}
+	return partial
+}
+
+// jsonLinesFormat posts the entries as newline delimited JSON.
+type jsonLinesFormat struct{}
+
+func (jsonLinesFormat) path() string {
+	return ""
+}
+
+func (jsonLinesFormat) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (jsonLinesFormat) encode(buf *bytes.Buffer, lines []shippedLine) error {
+	for _, line := range lines {
+		buf.Write(line.line)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+func (jsonLinesFormat) check([]byte, []shippedLine) error {
+	return nil
+}
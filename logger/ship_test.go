@@ -0,0 +1,401 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// shipRequest is a request received by a shipRecorder.
+type shipRequest struct {
+	path        string
+	contentType string
+	encoding    string
+	body        string
+}
+
+// shipRecorder is a log store answering with the statuses in turn, then 200,
+// and with the responses in turn, then response.
+type shipRecorder struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	requests  []shipRequest
+	statuses  []int
+	responses []string
+	response  string
+}
+
+func newShipRecorder(t *testing.T, statuses ...int) *shipRecorder {
+	rec := &shipRecorder{statuses: statuses}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("invalid gzip body: %v", err)
+				return
+			}
+			reader = zr
+		}
+		body, _ := io.ReadAll(reader)
+
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, shipRequest{
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			encoding:    r.Header.Get("Content-Encoding"),
+			body:        string(body),
+		})
+		status := http.StatusOK
+		if len(rec.statuses) > 0 {
+			status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+		}
+		response := rec.response
+		if len(rec.responses) > 0 {
+			response, rec.responses = rec.responses[0], rec.responses[1:]
+		}
+		rec.mu.Unlock()
+
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (rec *shipRecorder) received() []shipRequest {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]shipRequest(nil), rec.requests...)
+}
+
+// ship writes the lines to the Shipper, syncs and closes it.
+func ship(t *testing.T, s *Shipper, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		s.Write([]byte(line + "\n"))
+	}
+	s.Sync()
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func testShipperConfig(url string) ShipperConfig {
+	return ShipperConfig{
+		URL:           url,
+		FlushInterval: time.Hour,
+		MinBackoff:    time.Millisecond,
+		MaxBackoff:    time.Millisecond,
+	}
+}
+
+func TestShipperBodies(t *testing.T) {
+	lines := []string{`{"message":"first"}`, `{"message":"second"}`}
+	tests := []struct {
+		name        string
+		create      func(cfg ShipperConfig) *Shipper
+		path        string
+		contentType string
+		check       func(t *testing.T, body string)
+	}{
+		{
+			name: "loki",
+			create: func(cfg ShipperConfig) *Shipper {
+				return NewLokiShipper(cfg, map[string]string{"app": "api"})
+			},
+			path:        "/loki/api/v1/push",
+			contentType: "application/json",
+			check: func(t *testing.T, body string) {
+				var push lokiPush
+				if err := json.Unmarshal([]byte(body), &push); err != nil {
+					t.Fatalf("invalid push body %q: %v", body, err)
+				}
+				if len(push.Streams) != 1 || push.Streams[0].Stream["app"] != "api" {
+					t.Fatalf("unexpected streams %+v", push.Streams)
+				}
+				values := push.Streams[0].Values
+				if len(values) != 2 || values[0][1] != lines[0] || values[1][1] != lines[1] {
+					t.Errorf("unexpected values %q", values)
+				}
+				if values[0][0] == "" || strings.Trim(values[0][0], "0123456789") != "" {
+					t.Errorf("timestamp %q is not in nanoseconds", values[0][0])
+				}
+			},
+		},
+		{
+			name: "elasticsearch",
+			create: func(cfg ShipperConfig) *Shipper {
+				return NewElasticsearchShipper(cfg, "logs-api")
+			},
+			path:        "/_bulk",
+			contentType: "application/x-ndjson",
+			check: func(t *testing.T, body string) {
+				action := `{"create":{"_index":"logs-api"}}`
+				want := action + "\n" + lines[0] + "\n" + action + "\n" + lines[1] + "\n"
+				if body != want {
+					t.Errorf("body = %q, want %q", body, want)
+				}
+			},
+		},
+		{
+			name:        "json lines",
+			create:      NewHTTPShipper,
+			path:        "/ingest",
+			contentType: "application/x-ndjson",
+			check: func(t *testing.T, body string) {
+				if want := lines[0] + "\n" + lines[1] + "\n"; body != want {
+					t.Errorf("body = %q, want %q", body, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, compress := range []bool{false, true} {
+			name := tt.name
+			if compress {
+				name += " gzip"
+			}
+			t.Run(name, func(t *testing.T) {
+				rec := newShipRecorder(t)
+				cfg := testShipperConfig(rec.URL)
+				if tt.name == "json lines" {
+					cfg.URL += "/ingest"
+				}
+				cfg.Gzip = compress
+				ship(t, tt.create(cfg), lines...)
+
+				requests := rec.received()
+				if len(requests) != 1 {
+					t.Fatalf("got %d requests, want 1", len(requests))
+				}
+				req := requests[0]
+				if req.path != tt.path {
+					t.Errorf("path = %q, want %q", req.path, tt.path)
+				}
+				if req.contentType != tt.contentType {
+					t.Errorf("content type = %q, want %q", req.contentType, tt.contentType)
+				}
+				if compress != (req.encoding == "gzip") {
+					t.Errorf("content encoding = %q with gzip %v", req.encoding, compress)
+				}
+				tt.check(t, req.body)
+			})
+		}
+	}
+}
+
+func TestShipperRetries(t *testing.T) {
+	rec := newShipRecorder(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	s := NewHTTPShipper(testShipperConfig(rec.URL))
+	ship(t, s, `{"message":"retried"}`)
+
+	if requests := rec.received(); len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+	stats := s.Stats()
+	if stats.Retries != 2 || stats.Sent != 1 || stats.Failed != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestShipperSpillsAndReplays(t *testing.T) {
+	rec := newShipRecorder(t, http.StatusServiceUnavailable)
+	cfg := testShipperConfig(rec.URL)
+	cfg.MaxRetries = -1
+	cfg.SpillDir = t.TempDir()
+	s := NewHTTPShipper(cfg)
+
+	s.Write([]byte(`{"message":"spilled"}` + "\n"))
+	s.Sync()
+	if files, _ := filepath.Glob(filepath.Join(cfg.SpillDir, "spill-*.log")); len(files) != 1 {
+		t.Fatalf("got %d spilled files, want 1", len(files))
+	}
+	ship(t, s, `{"message":"sent"}`)
+
+	requests := rec.received()
+	if len(requests) != 3 || requests[2].body != `{"message":"spilled"}`+"\n" {
+		t.Fatalf("spilled entry not replayed: %+v", requests)
+	}
+	if files, _ := filepath.Glob(filepath.Join(cfg.SpillDir, "spill-*.log")); len(files) != 0 {
+		t.Errorf("replayed file not removed: %v", files)
+	}
+	if stats := s.Stats(); stats.Spilled != 1 || stats.Replayed != 1 || stats.Sent != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestShipperDropsRejectedBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		create   func(cfg ShipperConfig) *Shipper
+	}{
+		{name: "client error", status: http.StatusBadRequest, create: NewHTTPShipper},
+		{
+			name:     "bulk item rejected",
+			status:   http.StatusOK,
+			response: `{"errors":true,"items":[{"create":{"error":{"type":"mapper_parsing_exception","reason":"failed"}}}]}`,
+			create: func(cfg ShipperConfig) *Shipper {
+				return NewElasticsearchShipper(cfg, "logs")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newShipRecorder(t, tt.status)
+			rec.response = tt.response
+			cfg := testShipperConfig(rec.URL)
+			cfg.SpillDir = t.TempDir()
+			s := tt.create(cfg)
+
+			s.Write([]byte(`{"message":"rejected"}` + "\n"))
+			if err := s.Sync(); err == nil {
+				t.Error("Sync did not report the rejected batch")
+			}
+			rec.mu.Lock()
+			rec.response = ""
+			rec.mu.Unlock()
+			ship(t, s, `{"message":"accepted"}`)
+
+			if requests := rec.received(); len(requests) != 2 {
+				t.Fatalf("got %d requests, want the rejected batch sent once", len(requests))
+			}
+			if files, _ := filepath.Glob(filepath.Join(cfg.SpillDir, "spill-*.log")); len(files) != 0 {
+				t.Errorf("rejected batch spilled: %v", files)
+			}
+			if stats := s.Stats(); stats.Dropped != 1 || stats.Sent != 1 || stats.Spilled != 0 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+		})
+	}
+}
+
+func TestShipperReplaySkipsRejectedBatches(t *testing.T) {
+	rec := newShipRecorder(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	cfg := testShipperConfig(rec.URL)
+	cfg.MaxRetries = -1
+	cfg.SpillDir = t.TempDir()
+	s := NewHTTPShipper(cfg)
+
+	for _, message := range []string{"rejected", "kept"} {
+		s.Write([]byte(`{"message":"` + message + `"}` + "\n"))
+		s.Sync()
+		time.Sleep(time.Millisecond)
+	}
+	rec.mu.Lock()
+	rec.statuses = []int{http.StatusOK, http.StatusBadRequest}
+	rec.mu.Unlock()
+	ship(t, s, `{"message":"sent"}`)
+
+	// The spilled files are replayed after the batch accepted by the store.
+	requests := rec.received()
+	if len(requests) != 5 {
+		t.Fatalf("got %d requests, want 5", len(requests))
+	}
+	if want := `{"message":"kept"}` + "\n"; requests[4].body != want {
+		t.Errorf("replayed %q after the rejected file, want %q", requests[4].body, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(cfg.SpillDir, "spill-*.log")); len(files) != 0 {
+		t.Errorf("spilled files left: %v", files)
+	}
+	if stats := s.Stats(); stats.Dropped != 1 || stats.Replayed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// bufferLines adds the lines to the buffer of the Shipper without waking it, so
+// that a single flush sends them.
+func bufferLines(s *Shipper, lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range lines {
+		s.pending = append(s.pending, shippedLine{time: time.Now(), line: []byte(line)})
+		s.size += len(line)
+	}
+}
+
+func TestShipperStopsAfterFailedBatch(t *testing.T) {
+	lines := []string{`{"message":"first"}`, `{"message":"second"}`, `{"message":"third"}`}
+	for _, spill := range []bool{false, true} {
+		name := "buffered"
+		if spill {
+			name = "spilled"
+		}
+		t.Run(name, func(t *testing.T) {
+			rec := newShipRecorder(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+			cfg := testShipperConfig(rec.URL)
+			cfg.BatchSize = 1
+			cfg.MaxRetries = 1
+			if spill {
+				cfg.SpillDir = t.TempDir()
+			}
+			s := NewHTTPShipper(cfg)
+			defer s.Close(context.Background())
+
+			bufferLines(s, lines...)
+			if err := s.Sync(); err == nil {
+				t.Error("Sync did not report the failed batch")
+			}
+			// Only the first batch is sent, with one retry.
+			if requests := rec.received(); len(requests) != 2 {
+				t.Fatalf("got %d requests, want 2", len(requests))
+			}
+			stats := s.Stats()
+			if stats.Failed != 1 || stats.Retries != 1 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+			if spill && (stats.Spilled != 3 || stats.Buffered != 0) {
+				t.Errorf("the rest of the buffer was not spilled: %+v", stats)
+			}
+			if !spill && (stats.Dropped != 1 || stats.Buffered != 2) {
+				t.Errorf("the rest of the buffer was not kept: %+v", stats)
+			}
+
+			// The next flush sends the rest.
+			s.Sync()
+			if stats := s.Stats(); stats.Sent != 2 && !spill || stats.Replayed != 3 && spill {
+				t.Errorf("unexpected stats after the endpoint recovered %+v", stats)
+			}
+		})
+	}
+}
+
+func TestShipperRetriesThrottledBulkItems(t *testing.T) {
+	rec := newShipRecorder(t)
+	rec.responses = []string{`{"errors":true,"items":[
+		{"create":{"status":201}},
+		{"create":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}},
+		{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed"}}}
+	]}`}
+	s := NewElasticsearchShipper(testShipperConfig(rec.URL), "logs")
+
+	bufferLines(s, `{"message":"accepted"}`, `{"message":"throttled"}`, `{"message":"invalid"}`)
+	if err := s.Sync(); err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Sync: %v, want the rejected item", err)
+	}
+	s.Close(context.Background())
+
+	requests := rec.received()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if want := `{"create":{"_index":"logs"}}` + "\n" + `{"message":"throttled"}` + "\n"; requests[1].body != want {
+		t.Errorf("retried %q, want only the throttled item", requests[1].body)
+	}
+	if stats := s.Stats(); stats.Sent != 2 || stats.Dropped != 1 || stats.Retries != 1 || stats.Batches != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
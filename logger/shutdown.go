@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// Flusher flushes buffered log entries, e.g. AsyncQueue.Close or the Close
+// method of a network sink. It should return when the context is done.
+type Flusher func(ctx context.Context) error
+
+// flusherRegistry stores the flushers run by Shutdown.
+type flusherRegistry struct {
+	mu       sync.Mutex
+	nextID   int
+	flushers map[int]namedFlusher
+}
+
+// namedFlusher is a registered flusher with the name used in its errors.
+type namedFlusher struct {
+	name  string
+	flush Flusher
+}
+
+var flushers = &flusherRegistry{flushers: map[int]namedFlusher{}}
+
+// RegisterFlusher adds a flusher run by Shutdown before the default Logger is synced.
+// Flushers run in the order they were registered.
+// The returned function removes the flusher again.
+func RegisterFlusher(name string, flush Flusher) (unregister func()) {
+	flushers.mu.Lock()
+	defer flushers.mu.Unlock()
+	id := flushers.nextID
+	flushers.nextID++
+	flushers.flushers[id] = namedFlusher{name: name, flush: flush}
+
+	return func() {
+		flushers.mu.Lock()
+		defer flushers.mu.Unlock()
+		delete(flushers.flushers, id)
+	}
+}
+
+// Shutdown runs the registered flushers and syncs the default Logger,
+// giving up when the context is done. Sync errors of outputs that cannot be
+// synced, such as stderr attached to a terminal or a pipe, are ignored.
+// The other failures are returned together.
+func Shutdown(ctx context.Context) error {
+	flushers.mu.Lock()
+	ids := make([]int, 0, len(flushers.flushers))
+	for id := range flushers.flushers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	registered := make([]namedFlusher, len(ids))
+	for i, id := range ids {
+		registered[i] = flushers.flushers[id]
+	}
+	flushers.mu.Unlock()
+
+	var errs []error
+	for _, flusher := range registered {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", flusher.name, err))
+			continue
+		}
+		if err := realErrors(flusher.flush(ctx)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", flusher.name, err))
+		}
+	}
+
+	synced := make(chan error, 1)
+	go func() {
+		synced <- Default().Sync()
+	}()
+	select {
+	case err := <-synced:
+		if err = realErrors(err); err != nil {
+			errs = append(errs, fmt.Errorf("sync: %w", err))
+		}
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("sync: %w", ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
+// IsHarmlessSyncError returns true if the error only reports that an output,
+// such as a terminal or a pipe, does not support sync.
+func IsHarmlessSyncError(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
+
+// realErrors drops the harmless sync errors from a possibly joined error.
+func realErrors(err error) error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, err := range joined.Unwrap() {
+			if err = realErrors(err); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if IsHarmlessSyncError(err) {
+		return nil
+	}
+	return err
+}
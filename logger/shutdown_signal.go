@@ -0,0 +1,47 @@
+//go:build !windows
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ShutdownOnSignal runs Shutdown with the timeout on SIGTERM, then raises the
+// signal again so that the process terminates as it would have otherwise.
+// The returned function stops handling the signal.
+func ShutdownOnSignal(timeout time.Duration) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if err := Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to sync logger: %v\n", err)
+		}
+		cancel()
+
+		signal.Stop(signals)
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package logger
+
+import "time"
+
+// ShutdownOnSignal does nothing on Windows, which has no SIGTERM.
+func ShutdownOnSignal(timeout time.Duration) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler writing through a Logger, and so through its
+// outputs, encoders and options such as the stacks of error entries.
+type SlogHandler struct {
+	// logger skips the frames of slog, so that the caller of the slog.Logger
+	// methods starts the stacks of the entries.
+	logger        *zap.Logger
+	contextFields func(ctx context.Context) []zapcore.Field
+
+	// attrs are the attributes added outside any group. They are added
+	// after the context fields, as the fields of a log call are.
+	attrs []slog.Attr
+	// groups are the open groups, outermost first.
+	groups []slogGroup
+}
+
+// slogGroup is an open group with the attributes added to it.
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler creates a slog.Handler writing through the Logger.
+// The fields returned by contextFields, if set, are added first to every
+// record logged with a context.
+func NewSlogHandler(l *Logger, contextFields func(ctx context.Context) []zapcore.Field) *SlogHandler {
+	return &SlogHandler{
+		logger:        l.zap.WithOptions(zap.AddCallerSkip(2)),
+		contextFields: contextFields,
+	}
+}
+
+// SlogLevel converts a slog level to the zap level at or below it.
+func SlogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(SlogLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	checked := h.logger.Check(SlogLevel(record.Level), record.Message)
+	if checked == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		checked.Time = record.Time
+	}
+	if checked.Caller.Defined && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		checked.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	var fields []zapcore.Field
+	if h.contextFields != nil && ctx != nil {
+		fields = append(fields, h.contextFields(ctx)...)
+	}
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := h.groups[i]
+		grouped := append(group.attrs[:len(group.attrs):len(group.attrs)], attrs...)
+		attrs = nil
+		if len(grouped) > 0 {
+			attrs = []slog.Attr{{Key: group.name, Value: slog.GroupValue(grouped...)}}
+		}
+	}
+	fields = append(fields, slogFields(h.attrs)...)
+	checked.Write(append(fields, slogFields(attrs)...)...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	child := *h
+	if len(h.groups) == 0 {
+		child.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+		return &child
+	}
+
+	child.groups = append([]slogGroup(nil), h.groups...)
+	last := &child.groups[len(child.groups)-1]
+	last.attrs = append(last.attrs[:len(last.attrs):len(last.attrs)], attrs...)
+	return &child
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(h.groups[:len(h.groups):len(h.groups)], slogGroup{name: name})
+	return &child
+}
+
+// slogFields converts slog attributes to zap fields, dropping empty attributes
+// and groups and inlining groups without a key as slog does.
+func slogFields(attrs []slog.Attr) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) || attr.Value.Kind() == slog.KindGroup && len(attr.Value.Group()) == 0 {
+			continue
+		}
+		fields = append(fields, slogField(attr))
+	}
+	return fields
+}
+
+// slogField converts a resolved slog attribute to a zap field.
+func slogField(attr slog.Attr) zapcore.Field {
+	value := attr.Value
+	switch value.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, value.String())
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, value.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, value.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, value.Float64())
+	case slog.KindBool:
+		return zap.Bool(attr.Key, value.Bool())
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, value.Duration())
+	case slog.KindTime:
+		return zap.Time(attr.Key, value.Time())
+	case slog.KindGroup:
+		group := slogGroupObject(value.Group())
+		if attr.Key == "" {
+			return zap.Inline(group)
+		}
+		return zap.Object(attr.Key, group)
+	}
+
+	if err, ok := value.Any().(error); ok {
+		return zap.NamedError(attr.Key, err)
+	}
+	return zap.Any(attr.Key, value.Any())
+}
+
+// slogGroupObject encodes the attributes of a group as an object.
+type slogGroupObject []slog.Attr
+
+func (g slogGroupObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, field := range slogFields(g) {
+		field.AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedirectStdLog sends the output of the standard library's global logger to
+// the Logger at the level, reporting the callers of the log package.
+// The returned function restores the previous output.
+func RedirectStdLog(l *Logger, level zapcore.Level) (restore func(), err error) {
+	return zap.RedirectStdLogAt(l.Zap(), level)
+}
+
+// NewStdLog creates a standard library logger writing to the Logger at the level,
+// e.g. for libraries that take a *log.Logger.
+func NewStdLog(l *Logger, level zapcore.Level) (*log.Logger, error) {
+	return zap.NewStdLogAt(l.Zap(), level)
+}
+
+// NewHTTPErrorLog creates the ErrorLog of an http.Server, logging TLS handshake
+// errors, recovered handler panics and the other server errors at the level
+// with a logger named "http.server".
+// srv := &http.Server{ErrorLog: log.NewHTTPErrorLog(log.Default(), zapcore.WarnLevel)}
+func NewHTTPErrorLog(l *Logger, level zapcore.Level) *log.Logger {
+	errorLog, err := NewStdLog(l.Named("http.server"), level)
+	if err != nil {
+		// Only levels above Fatal are rejected, so fall back to Error for those.
+		errorLog, _ = NewStdLog(l.Named("http.server"), zapcore.ErrorLevel)
+	}
+	return errorLog
+}
@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// The sugared methods and functions format the message like fmt.Sprintf (the
+// f suffix) or take loosely typed key-value pairs (the w suffix), for code
+// migrating from printf-style loggers such as logrus. They call zap directly,
+// like the other methods, so that the caller is reported correctly.
+
+// Tracef formats and logs a message at level Trace.
+func (l *Logger) Tracef(template string, args ...interface{}) {
+	if enabled(l.zap, TraceLevel) {
+		l.zap.Log(TraceLevel, sprintf(template, args))
+	}
+}
+
+// Tracew logs a message at level Trace with key-value pairs, e.g. l.Tracew("msg", "id", 1).
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, TraceLevel) {
+		l.zap.Log(TraceLevel, msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Debugf formats and logs a message at level Debug.
+func (l *Logger) Debugf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.DebugLevel) {
+		l.zap.Debug(sprintf(template, args))
+	}
+}
+
+// Debugw logs a message at level Debug with key-value pairs, e.g. l.Debugw("msg", "id", 1).
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.DebugLevel) {
+		l.zap.Debug(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Infof formats and logs a message at level Info.
+func (l *Logger) Infof(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.InfoLevel) {
+		l.zap.Info(sprintf(template, args))
+	}
+}
+
+// Infow logs a message at level Info with key-value pairs, e.g. l.Infow("msg", "id", 1).
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.InfoLevel) {
+		l.zap.Info(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Warnf formats and logs a message at level Warn.
+func (l *Logger) Warnf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.WarnLevel) {
+		l.zap.Warn(sprintf(template, args))
+	}
+}
+
+// Warnw logs a message at level Warn with key-value pairs, e.g. l.Warnw("msg", "id", 1).
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.WarnLevel) {
+		l.zap.Warn(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Errorf formats and logs a message at level Error.
+func (l *Logger) Errorf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.ErrorLevel) {
+		l.zap.Error(sprintf(template, args))
+	}
+}
+
+// Errorw logs a message at level Error with key-value pairs, e.g. l.Errorw("msg", "id", 1).
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.ErrorLevel) {
+		l.zap.Error(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// DPanicf formats and logs a message at level DPanic. In development it then panics.
+func (l *Logger) DPanicf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.DPanicLevel) {
+		l.zap.DPanic(sprintf(template, args))
+	}
+}
+
+// DPanicw logs a message at level DPanic with key-value pairs, e.g. l.DPanicw("msg", "id", 1). In development it then panics.
+func (l *Logger) DPanicw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.DPanicLevel) {
+		l.zap.DPanic(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Panicf formats and logs a message at level Panic. It then panics.
+func (l *Logger) Panicf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.PanicLevel) {
+		l.zap.Panic(sprintf(template, args))
+	}
+}
+
+// Panicw logs a message at level Panic with key-value pairs, e.g. l.Panicw("msg", "id", 1). It then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.PanicLevel) {
+		l.zap.Panic(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Fatalf formats and logs a message at level Fatal. It then calls os.Exit(1).
+func (l *Logger) Fatalf(template string, args ...interface{}) {
+	if enabled(l.zap, zapcore.FatalLevel) {
+		l.zap.Fatal(sprintf(template, args))
+	}
+}
+
+// Fatalw logs a message at level Fatal with key-value pairs, e.g. l.Fatalw("msg", "id", 1). It then calls os.Exit(1).
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if enabled(l.zap, zapcore.FatalLevel) {
+		l.zap.Fatal(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Tracef formats and logs a message at level Trace on the standard logger.
+// log.Tracef("user %s logged in", name)
+func Tracef(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, TraceLevel) {
+		zl.Log(TraceLevel, sprintf(template, args))
+	}
+}
+
+// Tracew logs a message at level Trace with key-value pairs on the standard logger.
+// log.Tracew("user logged in", "user", name, "attempts", 3)
+func Tracew(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, TraceLevel) {
+		zl.Log(TraceLevel, msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Debugf formats and logs a message at level Debug on the standard logger.
+// log.Debugf("user %s logged in", name)
+func Debugf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.DebugLevel) {
+		zl.Debug(sprintf(template, args))
+	}
+}
+
+// Debugw logs a message at level Debug with key-value pairs on the standard logger.
+// log.Debugw("user logged in", "user", name, "attempts", 3)
+func Debugw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.DebugLevel) {
+		zl.Debug(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Infof formats and logs a message at level Info on the standard logger.
+// log.Infof("user %s logged in", name)
+func Infof(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.InfoLevel) {
+		zl.Info(sprintf(template, args))
+	}
+}
+
+// Infow logs a message at level Info with key-value pairs on the standard logger.
+// log.Infow("user logged in", "user", name, "attempts", 3)
+func Infow(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.InfoLevel) {
+		zl.Info(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Warnf formats and logs a message at level Warn on the standard logger.
+// log.Warnf("user %s logged in", name)
+func Warnf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.WarnLevel) {
+		zl.Warn(sprintf(template, args))
+	}
+}
+
+// Warnw logs a message at level Warn with key-value pairs on the standard logger.
+// log.Warnw("user logged in", "user", name, "attempts", 3)
+func Warnw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.WarnLevel) {
+		zl.Warn(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Errorf formats and logs a message at level Error on the standard logger.
+// log.Errorf("user %s logged in", name)
+func Errorf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.ErrorLevel) {
+		zl.Error(sprintf(template, args))
+	}
+}
+
+// Errorw logs a message at level Error with key-value pairs on the standard logger.
+// log.Errorw("user logged in", "user", name, "attempts", 3)
+func Errorw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.ErrorLevel) {
+		zl.Error(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// DPanicf formats and logs a message at level DPanic on the standard logger. In development it then panics.
+// log.DPanicf("user %s logged in", name)
+func DPanicf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.DPanicLevel) {
+		zl.DPanic(sprintf(template, args))
+	}
+}
+
+// DPanicw logs a message at level DPanic with key-value pairs on the standard logger. In development it then panics.
+// log.DPanicw("user logged in", "user", name, "attempts", 3)
+func DPanicw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.DPanicLevel) {
+		zl.DPanic(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Panicf formats and logs a message at level Panic on the standard logger. It then panics.
+// log.Panicf("user %s logged in", name)
+func Panicf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.PanicLevel) {
+		zl.Panic(sprintf(template, args))
+	}
+}
+
+// Panicw logs a message at level Panic with key-value pairs on the standard logger. It then panics.
+// log.Panicw("user logged in", "user", name, "attempts", 3)
+func Panicw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.PanicLevel) {
+		zl.Panic(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// Fatalf formats and logs a message at level Fatal on the standard logger. It then calls os.Exit(1).
+// log.Fatalf("user %s logged in", name)
+func Fatalf(template string, args ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.FatalLevel) {
+		zl.Fatal(sprintf(template, args))
+	}
+}
+
+// Fatalw logs a message at level Fatal with key-value pairs on the standard logger. It then calls os.Exit(1).
+// log.Fatalw("user logged in", "user", name, "attempts", 3)
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	if zl := Default().zap; enabled(zl, zapcore.FatalLevel) {
+		zl.Fatal(msg, sweetenFields(keysAndValues)...)
+	}
+}
+
+// enabled returns true if zl could log at the level. Levels from DPanic up
+// are always enabled, since they panic or exit even when not logged.
+func enabled(zl *zap.Logger, level zapcore.Level) bool {
+	return level >= zapcore.DPanicLevel || zl.Core().Enabled(level)
+}
+
+// sprintf formats the message, using the template as it is without arguments.
+func sprintf(template string, args []interface{}) string {
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// badKey is the key of a value without a key.
+const badKey = "!BADKEY"
+
+// sweetenFields converts key-value pairs to fields. Fields among the pairs are
+// used as they are, keys that are not strings are formatted, and a value
+// without a key is logged under "!BADKEY".
+func sweetenFields(keysAndValues []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(keysAndValues)/2+1)
+	for i := 0; i < len(keysAndValues); i++ {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			fields = append(fields, field)
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			fields = append(fields, zap.Any(badKey, keysAndValues[i]))
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+		i++
+	}
+	return fields
+}
@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogFormat is the message format of a Syslog.
+type SyslogFormat int
+
+const (
+	// RFC5424 is the structured syslog format of rsyslog and syslog-ng.
+	RFC5424 SyslogFormat = iota
+	// RFC3164 is the traditional BSD syslog format.
+	RFC3164
+)
+
+// SyslogFacility is the facility of the messages of a Syslog.
+// The kernel facility is reserved, so the zero value selects FacilityUser.
+type SyslogFacility int
+
+const (
+	FacilityUser SyslogFacility = iota + 1
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+)
+
+const (
+	FacilityLocal0 SyslogFacility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogConfig configures a Syslog.
+type SyslogConfig struct {
+	// Network is "unixgram", "unix", "udp" or "tcp". Without Network and
+	// Address the local syslog socket is used, e.g. /dev/log.
+	Network string
+	Address string
+	Format  SyslogFormat
+	// Facility defaults to FacilityUser.
+	Facility SyslogFacility
+	// AppName is the application name, or tag, of the messages.
+	// It defaults to the name of the executable.
+	AppName string
+	// Hostname defaults to the name of the host.
+	Hostname string
+	// Level selects the entries sent. It defaults to the level of the Logger.
+	Level zapcore.LevelEnabler
+}
+
+// localSyslogSockets are the usual paths of the local syslog socket.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Syslog sends the log entries of a Logger to a syslog daemon, mapping the
+// levels to syslog severities. The entries are encoded as JSON without the
+// time and level, which are in the syslog header. Attach it with WithSyslog.
+type Syslog struct {
+	cfg      SyslogConfig
+	pid      int
+	hostname string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewSyslog connects to the syslog daemon.
+func NewSyslog(cfg SyslogConfig) (*Syslog, error) {
+	if cfg.Facility == 0 {
+		cfg.Facility = FacilityUser
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if cfg.Network == "" && cfg.Address != "" {
+		cfg.Network = "unixgram"
+	}
+	s := &Syslog{cfg: cfg, pid: os.Getpid(), hostname: cfg.Hostname}
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return s, nil
+}
+
+// WithSyslog sends the entries of the Logger to the Syslog.
+func WithSyslog(s *Syslog) Option {
+	return func(o *options) {
+		o.tees = append(o.tees, func(cfg zap.Config, level zapcore.LevelEnabler) zapcore.Core {
+			if s.cfg.Level != nil {
+				level = s.cfg.Level
+			}
+			encoderConfig := cfg.EncoderConfig
+			encoderConfig.TimeKey = zapcore.OmitKey
+			encoderConfig.LevelKey = zapcore.OmitKey
+			return &syslogCore{LevelEnabler: level, enc: zapcore.NewJSONEncoder(encoderConfig), syslog: s}
+		})
+	}
+}
+
+// Close closes the connection. Later entries are dropped.
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// dial connects to the configured address, or to the first local socket.
+func (s *Syslog) dial() (net.Conn, error) {
+	if s.cfg.Network != "" {
+		return net.Dial(s.cfg.Network, s.cfg.Address)
+	}
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				s.cfg.Network, s.cfg.Address = network, path
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("syslog: no local syslog socket found")
+}
+
+// stream returns true if the messages must be framed.
+func (s *Syslog) stream() bool {
+	switch s.cfg.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+// SyslogSeverity returns the syslog severity of a level.
+func SyslogSeverity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 7 // debug
+	case level == zapcore.InfoLevel:
+		return 6 // informational
+	case level == zapcore.WarnLevel:
+		return 4 // warning
+	case level == zapcore.ErrorLevel:
+		return 3 // error
+	case level == zapcore.DPanicLevel:
+		return 2 // critical
+	case level == zapcore.PanicLevel:
+		return 1 // alert
+	default:
+		return 0 // emergency
+	}
+}
+
+// format builds the syslog message of an entry.
+func (s *Syslog) format(entry zapcore.Entry, msg []byte) []byte {
+	priority := int(s.cfg.Facility)*8 + SyslogSeverity(entry.Level)
+	var buf bytes.Buffer
+	if s.cfg.Format == RFC3164 {
+		fmt.Fprintf(&buf, "<%d>%s ", priority, entry.Time.Format(time.Stamp))
+		// Local daemons add the hostname themselves.
+		if !strings.HasPrefix(s.cfg.Network, "unix") {
+			buf.WriteString(s.hostname)
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(&buf, "%s[%d]: ", s.cfg.AppName, s.pid)
+	} else {
+		msgID := syslogHeaderField(entry.LoggerName, 32)
+		fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s - ",
+			priority, entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+			syslogHeaderField(s.hostname, 255), syslogHeaderField(s.cfg.AppName, 48), s.pid, msgID)
+	}
+	buf.Write(msg)
+
+	if !s.stream() {
+		return buf.Bytes()
+	}
+	if s.cfg.Format == RFC3164 {
+		// Newline framing: the message must be a single line.
+		framed := bytes.ReplaceAll(buf.Bytes(), []byte("\n"), []byte(" "))
+		return append(framed, '\n')
+	}
+	// Octet counting framing of RFC 6587.
+	return append([]byte(fmt.Sprintf("%d ", buf.Len())), buf.Bytes()...)
+}
+
+// syslogHeaderField returns the value as a printable header field of at most
+// max characters, or "-" if it is empty.
+func syslogHeaderField(value string, max int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(field) > max {
+		field = field[:max]
+	}
+	if field == "" {
+		return "-"
+	}
+	return field
+}
+
+// write sends a message, reconnecting once if the connection was lost.
+func (s *Syslog) write(message []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				s.conn = nil
+				return err
+			}
+		}
+		if _, err = s.conn.Write(message); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("syslog: %w", err)
+}
+
+// syslogCore writes the entries of a Logger to a Syslog.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	syslog *Syslog
+}
+
+func (sc *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := sc.enc.Clone()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return &syslogCore{LevelEnabler: sc.LevelEnabler, enc: enc, syslog: sc.syslog}
+}
+
+func (sc *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sc.Enabled(entry.Level) {
+		return checked.AddCore(entry, sc)
+	}
+	return checked
+}
+
+func (sc *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := sc.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return sc.syslog.write(sc.syslog.format(entry, bytes.TrimRight(buf.Bytes(), "\n")))
+}
+
+func (sc *syslogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newSinkLogger creates a Logger writing only to the sinks of the options.
+func newSinkLogger(t *testing.T, opts ...Option) *Logger {
+	t.Helper()
+	cfg := DefaultConfig
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	cfg.OutputPaths = nil
+	l, err := New(cfg, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return l
+}
+
+// readOctetCounted reads a message framed with octet counting.
+func readOctetCounted(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	length, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatalf("reading frame length: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		t.Fatalf("invalid frame length %q", length)
+	}
+	message := make([]byte, n)
+	if _, err := io.ReadFull(r, message); err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	return string(message)
+}
+
+// acceptOne accepts a connection of the listener.
+func acceptOne(t *testing.T, listener net.Listener) <-chan net.Conn {
+	t.Helper()
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(conns)
+			return
+		}
+		conns <- conn
+	}()
+	return conns
+}
+
+func TestSyslogRFC5424OverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	conns := acceptOne(t, listener)
+
+	s, err := NewSyslog(SyslogConfig{
+		Network:  "tcp",
+		Address:  listener.Addr().String(),
+		Facility: FacilityLocal3,
+		AppName:  "api",
+		Hostname: "web-1",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslog: %v", err)
+	}
+	defer s.Close()
+	l := newSinkLogger(t, WithSyslog(s)).Named("db")
+	l.Warn("pool exhausted", zap.String("detail", "first\nsecond"))
+	l.Error("query failed")
+
+	conn := <-conns
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+
+	first := readOctetCounted(t, r)
+	// Local3 is facility 19 and Warn severity 4: 19*8+4.
+	prefix := "<156>1 "
+	if !strings.HasPrefix(first, prefix) {
+		t.Fatalf("message %q does not start with %q", first, prefix)
+	}
+	header := strings.SplitN(strings.TrimPrefix(first, prefix), " ", 7)
+	if len(header) != 7 {
+		t.Fatalf("incomplete header in %q", first)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, header[0]); err != nil {
+		t.Errorf("invalid timestamp %q: %v", header[0], err)
+	}
+	want := []string{"web-1", "api", strconv.Itoa(os.Getpid()), "db", "-"}
+	for i, value := range want {
+		if header[i+1] != value {
+			t.Errorf("header field %d = %q, want %q", i+1, header[i+1], value)
+		}
+	}
+	if msg := header[6]; !strings.Contains(msg, `"message":"pool exhausted"`) ||
+		!strings.Contains(msg, `"detail":"first\nsecond"`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	// Error severity 3: 19*8+3.
+	if second := readOctetCounted(t, r); !strings.HasPrefix(second, "<155>1 ") {
+		t.Errorf("second message %q has the wrong priority", second)
+	}
+}
+
+func TestSyslogRFC3164OverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	conns := acceptOne(t, listener)
+
+	s, err := NewSyslog(SyslogConfig{
+		Network:  "tcp",
+		Address:  listener.Addr().String(),
+		Format:   RFC3164,
+		AppName:  "api",
+		Hostname: "web-1",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslog: %v", err)
+	}
+	defer s.Close()
+	l := newSinkLogger(t, WithSyslog(s))
+	l.Info("multi\nline")
+	l.Debug("second")
+
+	conn := <-conns
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+
+	first, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	// User is facility 1 and Info severity 6: 1*8+6.
+	if !strings.HasPrefix(first, "<14>") {
+		t.Errorf("message %q has the wrong priority", first)
+	}
+	stamp := first[len("<14>") : len("<14>")+len(time.Stamp)]
+	if _, err := time.Parse(time.Stamp, stamp); err != nil {
+		t.Errorf("invalid timestamp %q: %v", stamp, err)
+	}
+	tag := fmt.Sprintf(" web-1 api[%d]: ", os.Getpid())
+	if !strings.Contains(first, tag) {
+		t.Errorf("message %q lacks the hostname and tag %q", first, tag)
+	}
+	if !strings.HasSuffix(first, `"message":"multi\nline"}`+"\n") {
+		t.Errorf("message %q is not a single line ending the frame", first)
+	}
+
+	second, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	// Debug severity 7: 1*8+7.
+	if !strings.HasPrefix(second, "<15>") {
+		t.Errorf("second message %q has the wrong priority", second)
+	}
+}
+
+func TestSyslogOverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s, err := NewSyslog(SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: FacilityDaemon,
+		AppName:  "api",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslog: %v", err)
+	}
+	defer s.Close()
+	newSinkLogger(t, WithSyslog(s)).Error("failed")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("reading datagram: %v", err)
+	}
+	// Datagrams are not framed. Daemon is facility 3 and Error severity 3: 3*8+3.
+	if message := string(buf[:n]); !strings.HasPrefix(message, "<27>1 ") {
+		t.Errorf("unexpected datagram %q", message)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level    zapcore.Level
+		severity int
+	}{
+		{TraceLevel, 7},
+		{zapcore.DebugLevel, 7},
+		{zapcore.InfoLevel, 6},
+		{zapcore.WarnLevel, 4},
+		{zapcore.ErrorLevel, 3},
+		{zapcore.DPanicLevel, 2},
+		{zapcore.PanicLevel, 1},
+		{zapcore.FatalLevel, 0},
+	}
+	for _, tt := range tests {
+		if severity := SyslogSeverity(tt.level); severity != tt.severity {
+			t.Errorf("SyslogSeverity(%v) = %d, want %d", tt.level, severity, tt.severity)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tracerlogger
+
+import (
+	"errors"
+	"runtime"
+)
+
+// StackError is an error carrying the stack where it was created.
+// The logger logs the stack with the error.
+type StackError struct {
+	// Code is empty for errors created with WithStack.
+	Code CodeError
+	Err  error
+
+	callers []uintptr
+}
+
+// WithStack wraps the error with the stack of the caller. Errors already
+// carrying a stack are returned unchanged.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	var stackErr *StackError
+	if errors.As(err, &stackErr) {
+		return err
+	}
+	return newStackError("", err)
+}
+
+// Wrap wraps the cause with the CodeError and the stack of the caller.
+// The cause may be nil, and so may the CodeError.
+func (ce CodeError) Wrap(err error) *StackError {
+	return newStackError(ce, err)
+}
+
+// newStackError creates a StackError with the stack of the caller of its caller.
+func newStackError(code CodeError, err error) *StackError {
+	callers := make([]uintptr, 32)
+	n := runtime.Callers(3, callers)
+	return &StackError{Code: code, Err: err, callers: callers[:n]}
+}
+
+// Error returns the message of the CodeError followed by the cause, or
+// "<nil>" if the StackError has neither.
+func (se *StackError) Error() string {
+	switch {
+	case se.Code == "" && se.Err == nil:
+		return "<nil>"
+	case se.Code == "":
+		return se.Err.Error()
+	case se.Err == nil:
+		return se.Code.Error()
+	}
+	return se.Code.Error() + ": " + se.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (se *StackError) Unwrap() error {
+	return se.Err
+}
+
+// Is reports whether the target is the CodeError of the StackError.
+func (se *StackError) Is(target error) bool {
+	code, ok := target.(CodeError)
+	return ok && se.Code != "" && code == se.Code
+}
+
+// CodeError returns the code of the StackError, or CodeInternalServerError
+// if it has none.
+func (se *StackError) CodeError() CodeError {
+	if se.Code == "" {
+		return CodeInternalServerError
+	}
+	return se.Code
+}
+
+// Callers returns the program counters of the stack where the error was created.
+func (se *StackError) Callers() []uintptr {
+	return se.callers
+}
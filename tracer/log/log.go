@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"sync/atomic"
 
 	log "github.com/jimxshaw/tracerlogger/logger"
 	"github.com/jimxshaw/tracerlogger/tracer"
@@ -10,39 +11,104 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// skippedLogger is the default logger with one more frame of caller skip,
+// so that log lines report the caller of the functions in this package.
+type skippedLogger struct {
+	source *log.Logger
+	logger *log.Logger
+}
+
+var skipped atomic.Pointer[skippedLogger]
+
+// logger returns the default logger adjusted for the functions in this package.
+// It is rebuilt whenever the default logger has been replaced.
+func logger() *log.Logger {
+	source := log.Default()
+	if cached := skipped.Load(); cached != nil && cached.source == source {
+		return cached.logger
+	}
+
+	cached := &skippedLogger{
+		source: source,
+		logger: source.WithCallerSkip(1),
+	}
+	skipped.Store(cached)
+	return cached.logger
+}
+
+// Trace logs a message at level Trace on the standard logger with trace details.
+func Trace(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger().Trace(msg, appendFieldsWithTrace(ctx, fields...)...)
+}
+
 // Info logs a message at level Info on the standard logger with trace details.
 func Info(ctx context.Context, msg string, fields ...zapcore.Field) {
-	log.Info(msg, appendFieldsWithTrace(ctx, fields...)...)
+	logger().Info(msg, appendFieldsWithTrace(ctx, fields...)...)
 }
 
 // Debug logs a message at level Debug on the standard logger with trace details.
 func Debug(ctx context.Context, msg string, fields ...zapcore.Field) {
-	log.Debug(msg, appendFieldsWithTrace(ctx, fields...)...)
+	logger().Debug(msg, appendFieldsWithTrace(ctx, fields...)...)
 }
 
 // Error logs a message at level Error on the standard logger with trace details.
 func Error(ctx context.Context, msg string, fields ...zapcore.Field) {
-	log.Error(msg, appendFieldsWithTrace(ctx, fields...)...)
+	logger().Error(msg, appendFieldsWithTrace(ctx, fields...)...)
+}
+
+// DPanic logs a message at level DPanic on the standard logger with trace details.
+// In development, it then panics.
+func DPanic(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger().DPanic(msg, appendFieldsWithTrace(ctx, fields...)...)
+}
+
+// Panic logs a message at level Panic on the standard logger with trace details.
+// After logging, it panics.
+func Panic(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger().Panic(msg, appendFieldsWithTrace(ctx, fields...)...)
 }
 
 // Fatal logs a message at level Fatal on the standard logger with trace details.
 func Fatal(ctx context.Context, msg string, fields ...zapcore.Field) {
-	log.Fatal(msg, appendFieldsWithTrace(ctx, fields...)...)
+	logger().Fatal(msg, appendFieldsWithTrace(ctx, fields...)...)
 }
 
 // Warn logs a message at level Warn on the standard logger with trace details.
 func Warn(ctx context.Context, msg string, fields ...zapcore.Field) {
-	log.Warn(msg, appendFieldsWithTrace(ctx, fields...)...)
+	logger().Warn(msg, appendFieldsWithTrace(ctx, fields...)...)
+}
+
+// fieldsCtxKey is the context key for the fields added with WithFields.
+type fieldsCtxKey struct{}
+
+// WithFields returns a copy of the context carrying fields that are added to
+// every log line written with it, e.g. the authenticated subject.
+func WithFields(ctx context.Context, fields ...zapcore.Field) context.Context {
+	existing := ctxFields(ctx)
+	combined := make([]zapcore.Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, fieldsCtxKey{}, combined)
+}
+
+// ctxFields returns the fields added to the context with WithFields.
+func ctxFields(ctx context.Context) []zapcore.Field {
+	fields, _ := ctx.Value(fieldsCtxKey{}).([]zapcore.Field)
+	return fields
 }
 
 // traceField extracts the trace details from the context and returns it as a zap field.
 func traceField(ctx context.Context) zapcore.Field {
 	propagator := tracer.ExtractFromCtx(ctx)
 	field := propagator.Sanitize()
-	return zap.Any("Trace", field)
+	return zap.Object(log.TraceFieldKey, field)
 }
 
-// appendFieldsWithTrace combines trace details from context with other fields.
+// appendFieldsWithTrace combines trace details and fields from context with other fields.
 func appendFieldsWithTrace(ctx context.Context, fields ...zapcore.Field) []zapcore.Field {
-	return append([]zapcore.Field{traceField(ctx)}, fields...)
+	contextFields := ctxFields(ctx)
+	combined := make([]zapcore.Field, 0, 1+len(contextFields)+len(fields))
+	combined = append(combined, traceField(ctx))
+	combined = append(combined, contextFields...)
+	return append(combined, fields...)
 }
@@ -0,0 +1,21 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSlogHandler creates a slog.Handler writing through the logger that adds
+// the trace details and the WithFields fields of the context passed to
+// InfoContext and friends, producing the same records as the functions of
+// this package.
+// slog.SetDefault(slog.New(log.NewSlogHandler(logger.Default())))
+func NewSlogHandler(l *log.Logger) slog.Handler {
+	return log.NewSlogHandler(l, func(ctx context.Context) []zapcore.Field {
+		return appendFieldsWithTrace(ctx)
+	})
+}
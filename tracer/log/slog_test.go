@@ -0,0 +1,70 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+	"github.com/jimxshaw/tracerlogger/tracer"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// variableParts matches the parts of a record that differ between two log calls.
+var variableParts = regexp.MustCompile(`"time":"[^"]*"|\.go:\d+`)
+
+func TestSlogHandlerMatchesLog(t *testing.T) {
+	cfg := log.DefaultConfig
+	cfg.Encoding = "json"
+	cfg.EncoderConfig.StacktraceKey = "stacktrace"
+	path := filepath.Join(t.TempDir(), "log.json")
+	cfg.OutputPaths = []string{path}
+	l, err := log.New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer log.SetDefault(l)()
+
+	ctx := tracer.InjectInCtx(context.Background(), tracer.NewTracerContext())
+	ctx = WithFields(ctx, zap.String("subject", "user-1"))
+	failure := errors.New("connection refused")
+
+	Error(ctx, "query failed", zap.String("db", "orders"), zap.Int("attempt", 2), zap.Error(failure))
+	slog.New(NewSlogHandler(l)).With("db", "orders").
+		ErrorContext(ctx, "query failed", "attempt", 2, "error", failure)
+	Info(ctx, "served", zap.Object("request", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("method", "GET")
+		return nil
+	})))
+	slog.New(NewSlogHandler(l)).WithGroup("request").InfoContext(ctx, "served", "method", "GET")
+	l.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d records:\n%s", len(lines), data)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		logged := variableParts.ReplaceAllString(lines[i], "")
+		slogged := variableParts.ReplaceAllString(lines[i+1], "")
+		if logged != slogged {
+			t.Errorf("records differ:\nlog:  %s\nslog: %s", lines[i], lines[i+1])
+		}
+	}
+	if !strings.Contains(lines[1], `"stacktrace":"github.com/jimxshaw/tracerlogger/tracer/log.TestSlogHandlerMatchesLog`) {
+		t.Errorf("the slog error has no stack starting at the caller: %s", lines[1])
+	}
+	if !strings.Contains(lines[1], `"caller":"log/slog_test.go`) {
+		t.Errorf("the slog record has the wrong caller: %s", lines[1])
+	}
+}
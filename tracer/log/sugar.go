@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/jimxshaw/tracerlogger/logger"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Tracef formats and logs a message at level Trace on the standard logger with trace details.
+func Tracef(ctx context.Context, template string, args ...interface{}) {
+	if l := logger(); l.Enabled(log.TraceLevel) {
+		l.Trace(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+	}
+}
+
+// Tracew logs a message at level Trace with key-value pairs on the standard logger with trace details.
+func Tracew(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Tracew(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Debugf formats and logs a message at level Debug on the standard logger with trace details.
+func Debugf(ctx context.Context, template string, args ...interface{}) {
+	if l := logger(); l.Enabled(zapcore.DebugLevel) {
+		l.Debug(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+	}
+}
+
+// Debugw logs a message at level Debug with key-value pairs on the standard logger with trace details.
+func Debugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Debugw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Infof formats and logs a message at level Info on the standard logger with trace details.
+func Infof(ctx context.Context, template string, args ...interface{}) {
+	if l := logger(); l.Enabled(zapcore.InfoLevel) {
+		l.Info(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+	}
+}
+
+// Infow logs a message at level Info with key-value pairs on the standard logger with trace details.
+func Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Infow(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Warnf formats and logs a message at level Warn on the standard logger with trace details.
+func Warnf(ctx context.Context, template string, args ...interface{}) {
+	if l := logger(); l.Enabled(zapcore.WarnLevel) {
+		l.Warn(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+	}
+}
+
+// Warnw logs a message at level Warn with key-value pairs on the standard logger with trace details.
+func Warnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Warnw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Errorf formats and logs a message at level Error on the standard logger with trace details.
+func Errorf(ctx context.Context, template string, args ...interface{}) {
+	if l := logger(); l.Enabled(zapcore.ErrorLevel) {
+		l.Error(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+	}
+}
+
+// Errorw logs a message at level Error with key-value pairs on the standard logger with trace details.
+func Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Errorw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// DPanicf formats and logs a message at level DPanic on the standard logger with trace details. In development it then panics.
+func DPanicf(ctx context.Context, template string, args ...interface{}) {
+	logger().DPanic(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+}
+
+// DPanicw logs a message at level DPanic with key-value pairs on the standard logger with trace details. In development it then panics.
+func DPanicw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().DPanicw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Panicf formats and logs a message at level Panic on the standard logger with trace details. It then panics.
+func Panicf(ctx context.Context, template string, args ...interface{}) {
+	logger().Panic(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+}
+
+// Panicw logs a message at level Panic with key-value pairs on the standard logger with trace details. It then panics.
+func Panicw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Panicw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// Fatalf formats and logs a message at level Fatal on the standard logger with trace details. It then calls os.Exit(1).
+func Fatalf(ctx context.Context, template string, args ...interface{}) {
+	logger().Fatal(sprintf(template, args), appendFieldsWithTrace(ctx)...)
+}
+
+// Fatalw logs a message at level Fatal with key-value pairs on the standard logger with trace details. It then calls os.Exit(1).
+func Fatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	logger().Fatalw(msg, appendPairsWithTrace(ctx, keysAndValues)...)
+}
+
+// sprintf formats the message like the sugared functions of the logger package.
+func sprintf(template string, args []interface{}) string {
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// appendPairsWithTrace prepends the trace details and fields from context to key-value pairs.
+func appendPairsWithTrace(ctx context.Context, keysAndValues []interface{}) []interface{} {
+	fields := appendFieldsWithTrace(ctx)
+	combined := make([]interface{}, 0, len(fields)+len(keysAndValues))
+	for _, field := range fields {
+		combined = append(combined, field)
+	}
+	return append(combined, keysAndValues...)
+}
@@ -15,7 +15,9 @@ import (
 	"strings"
 	"time"
 
-	util "github.com/jimxshaw/tracerlogger"
+	"github.com/jimxshaw/tracerlogger/internal/random"
+
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -167,6 +169,13 @@ type TraceField struct {
 	SpandID string `json:"span_id"`
 }
 
+// MarshalLogObject encodes the TraceField for zap with the same keys as its JSON.
+func (tf TraceField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("trace_id", tf.TraceID)
+	enc.AddString("span_id", tf.SpandID)
+	return nil
+}
+
 func (tc *TracerContext) String() string {
 	return fmt.Sprintf(
 		"%02x-%s-%s-%s",
@@ -340,6 +349,13 @@ func ExtractFromCtx(ctx context.Context) Propagator {
 	return NewTracerContext()
 }
 
+// FromCtx returns the TracerContext stored in the context by the middleware.
+// Unlike ExtractFromCtx it does not generate a new trace when none is present.
+func FromCtx(ctx context.Context) (*TracerContext, bool) {
+	ctxValue, ok := ctx.Value(traceCtxKey).(*TracerContext)
+	return ctxValue, ok
+}
+
 // InjectInRequest the trace information in the request context.
 func InjectInRequest(r *http.Request, trace *TracerContext) *http.Request {
 	ctx := InjectInCtx(r.Context(), trace)
@@ -398,11 +414,11 @@ func generateTrace(r *http.Request) (traceID, bool) {
 		ipHex = ipToHex(ip)
 	} else {
 		isInternalRequest = false
-		ipHex, _ = util.RandomHex(4)
+		ipHex, _ = random.Hex(4)
 
 	}
 	currentTime := makeTimestamp()
-	uniqueID, _ := util.RandomHex(5)
+	uniqueID, _ := random.Hex(5)
 	traceIDHex := fmt.Sprintf("%s%d0%s", ipHex, currentTime, uniqueID)
 	trace, _ := HexToTraceID(traceIDHex)
 
@@ -411,9 +427,9 @@ func generateTrace(r *http.Request) (traceID, bool) {
 
 // NewTracerContext creates a new TracerContext.
 func NewTracerContext() *TracerContext {
-	ipHex, _ := util.RandomHex(4)
+	ipHex, _ := random.Hex(4)
 	currentTime := makeTimestamp()
-	uniqueID, _ := util.RandomHex(5)
+	uniqueID, _ := random.Hex(5)
 	traceIDHex := fmt.Sprintf("%s%d0%s", ipHex, currentTime, uniqueID)
 	trace, _ := HexToTraceID(traceIDHex)
 
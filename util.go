@@ -1,11 +1,10 @@
 package tracerlogger
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"net/http"
 
+	"github.com/jimxshaw/tracerlogger/internal/random"
 	log "github.com/jimxshaw/tracerlogger/logger"
 	"go.uber.org/zap"
 )
@@ -22,7 +21,9 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	json.NewEncoder(w).Encode(payload)
 }
 
-// RespondWithJSON send a JSON-formatted error response.
+// RespondWithError send a JSON-formatted error response.
+// The hooks receive the CodeError found in the chain of err, or the general
+// CodeError of the status, e.g. CodeNotFound for a 404.
 func RespondWithError(w http.ResponseWriter, code int, err error) {
 	log.Error("request with error", zap.Error(err))
 	if err == nil {
@@ -33,16 +34,13 @@ func RespondWithError(w http.ResponseWriter, code int, err error) {
 				"error": "Something went wrong. Please try again or contact site administrators.",
 			},
 		)
-		return
+	} else {
+		RespondWithJSON(w, code, map[string]string{"error": err.Error()})
 	}
-	RespondWithJSON(w, code, map[string]string{"error": err.Error()})
+	fireHooks(newErrorEvent(w, nil, codeOf(err, code), code, err))
 }
 
 // RandomHex generates a random hex value.
 func RandomHex(n int) (string, error) {
-	bytes := make([]byte, n)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
+	return random.Hex(n)
 }